@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"neon/internal/clock"
+	"neon/internal/config"
+	"neon/internal/persona"
+	"neon/internal/storage"
+	"neon/internal/telemetry"
+)
+
+// root is the agent's root directory (its data/ tree lives below it), cfg
+// its effective configuration and clk the clock it runs on. main sets them
+// before running a command.
+var (
+	root = "."
+	cfg  = config.Default()
+	clk  = clock.System
+)
+
+// newLogger starts a logger for the agent under root, tuned by cfg and
+// running on clk.
+func newLogger() *telemetry.Logger {
+	return telemetry.NewLogger(root, telemetry.Options{
+		Buffer:         cfg.Telemetry.Buffer,
+		HealthInterval: time.Duration(cfg.Telemetry.HealthInterval),
+	}, clk)
+}
+
+// envOr returns the environment variable key, or def if it is unset.
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// setFlags collects repeated -set key=value flags.
+type setFlags []string
+
+func (s *setFlags) String() string { return strings.Join(*s, " ") }
+
+func (s *setFlags) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return fmt.Errorf("want key=value, got %q", v)
+	}
+	*s = append(*s, v)
+	return nil
+}
+
+// loadConfig builds the configuration: defaults, then the file at path
+// (or $NEON_CONFIG, or neon.json in root if it exists), then NEON_*
+// environment variables, then -set flags, then a non-zero seed. A seeded
+// configuration has its wall-clock timers stopped. The result is validated,
+// including the lexicon it names.
+func loadConfig(path string, sets setFlags, seed int64) (config.Config, error) {
+	if path == "" {
+		path = os.Getenv("NEON_CONFIG")
+	}
+	if def := filepath.Join(root, config.FileName); path == "" && storage.Exists(def) {
+		path = def
+	}
+	c := config.Default()
+	if path != "" {
+		var err error
+		if c, err = config.Load(path); err != nil {
+			return c, err
+		}
+	}
+	if err := c.ApplyEnv(os.LookupEnv); err != nil {
+		return c, err
+	}
+	for _, kv := range sets {
+		k, v, _ := strings.Cut(kv, "=")
+		if err := c.Set(strings.TrimSpace(k), v); err != nil {
+			return c, err
+		}
+	}
+	if seed != 0 {
+		c.Agent.Seed = seed
+	}
+	if c.Deterministic() {
+		c.StopTimers()
+	}
+	if err := c.Validate(); err != nil {
+		return c, err
+	}
+	// The agent falls back to the built-in lexicon; catch a bad one here.
+	if c.Paths.Lexicon != "" {
+		if _, err := persona.LoadLexicon(config.Path(root, c.Paths.Lexicon)); err != nil {
+			return c, fmt.Errorf("paths.lexicon: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// showConfig prints the effective configuration as JSON, followed by the
+// keys that can be overridden.
+func showConfig() {
+	data, _ := json.MarshalIndent(cfg, "", "  ")
+	fmt.Println(string(data))
+	fmt.Println()
+	fmt.Println("Override with -set key=value or the environment:")
+	for _, k := range cfg.Keys() {
+		fmt.Printf("  %-32s %s\n", k, config.EnvName(k))
+	}
+}
+
+// listProfiles prints the profiles under dataRoot.
+func listProfiles(dataRoot string) {
+	names, err := config.Profiles(dataRoot)
+	if err != nil {
+		log.Fatalf("profile list failed: %v", err)
+	}
+	if len(names) == 0 {
+		fmt.Println("No profiles.")
+	}
+	for _, n := range names {
+		fmt.Printf("%-20s %s\n", n, filepath.Join(config.ProfilesDir(dataRoot), n))
+	}
+}
@@ -5,24 +5,79 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	"neon/internal/agent"
+	"neon/internal/config"
+	"neon/internal/perception"
 	"neon/internal/storage"
-	"neon/internal/telemetry"
+	"neon/pkg/structs"
 )
 
 func main() {
-	cmd := flag.String("cmd", "", "command: run | snapshot-save | snapshot-restore")
-	file := flag.String("file", "", "snapshot file (for restore)")
+	cmd := flag.String("cmd", "", "command: run | snapshot-save | snapshot-restore | snapshot-migrate | snapshot-list | snapshot-diff | snapshot-prune | policy-history | policy-revert | proposal-add | proposal-list | proposal-approve | proposal-reject | proposal-rollback | config-show | profile-list")
+	file := flag.String("file", "", "snapshot file (for restore/migrate)")
 	notes := flag.String("notes", "", "notes for snapshot")
+	input := flag.String("input", "", "read input lines from this file instead of the console")
+	follow := flag.Bool("follow", false, "keep waiting for new lines appended to -input")
+	keepLast := flag.Int("keep-last", 0, "snapshot-prune: keep the newest N snapshots")
+	keepDaily := flag.Int("keep-daily", 0, "snapshot-prune: keep the newest snapshot of each of the last N days")
+	version := flag.Int("version", 0, "policy version (for policy-revert)")
+	dryRun := flag.Bool("dry-run", false, "snapshot-prune: only report what would be removed")
+	dataRoot := flag.String("data", envOr("NEON_DATA", "."), "root directory holding the agent's data/ tree (default $NEON_DATA or .)")
+	profile := flag.String("profile", os.Getenv("NEON_PROFILE"), "run an isolated agent profile under -data/profiles/NAME (default $NEON_PROFILE)")
+	configPath := flag.String("config", "", "JSON config file (default $NEON_CONFIG, else neon.json in the agent root if present)")
+	seed := flag.Int64("seed", 0, "seed randomness and run on a simulated clock, so the same input gives the same output and events (0 = not deterministic)")
+	var sets setFlags
+	flag.Var(&sets, "set", "override a config setting, e.g. -set mood.decay=0.1 (repeatable)")
+
+	var pf proposalFlags
+	flag.StringVar(&pf.id, "id", "", "proposal id (for approve/reject/rollback)")
+	flag.StringVar(&pf.kind, "kind", "", "proposal kind: rule_add | rule_edit | rule_delete | mood_threshold | weight_prune")
+	flag.StringVar(&pf.word, "word", "", "rule trigger word or weight to prune")
+	flag.StringVar(&pf.mood, "mood", "", "mood condition for rule_add")
+	flag.StringVar(&pf.then, "then", "", "rule response for rule_add/rule_edit")
+	flag.Float64Var(&pf.threshold, "threshold", 0, "new mood threshold for mood_threshold")
+	flag.IntVar(&pf.minCount, "min-count", 0, "prune words seen fewer times than this")
+	flag.StringVar(&pf.reason, "reason", "", "why the proposal is made")
 	flag.Parse()
+	pf.notes = *notes
+
+	var err error
+	if root, err = config.ProfileRoot(*dataRoot, *profile); err != nil {
+		log.Fatal(err)
+	}
+	if cfg, err = loadConfig(*configPath, sets, *seed); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	clk = cfg.Clock()
 
 	switch *cmd {
+	case "config-show":
+		showConfig()
+		return
+
+	case "profile-list":
+		listProfiles(*dataRoot)
+		return
+
 	case "snapshot-save":
-		path, err := storage.SaveSnapshot(".", map[string]any{"id": "NEON"}, []string{}, map[string]float64{}, map[string]bool{}, *notes)
+		logger := newLogger()
+		defer logger.Close()
+		ag := agent.NewAgentWith(logger, root, cfg)
+
+		path, err := ag.Snapshot(*notes)
 		if err != nil {
+			logger.Close()
 			log.Fatalf("snapshot save failed: %v", err)
 		}
+		logger.Log(structs.NewEvent("SNAPSHOT", "operator", map[string]any{
+			"path":  path,
+			"notes": *notes,
+		}))
 		fmt.Println("Snapshot saved to", path)
 		return
 
@@ -34,17 +89,101 @@ func main() {
 		if err != nil {
 			log.Fatalf("snapshot restore failed: %v", err)
 		}
+
+		logger := newLogger()
+		defer logger.Close()
+		ag := agent.NewAgentWith(logger, root, cfg)
+		if err := ag.Restore(snap); err != nil {
+			logger.Close()
+			log.Fatalf("snapshot restore failed: %v", err)
+		}
+		logger.Log(structs.NewEvent("RESTORE", "operator", map[string]any{
+			"path":      *file,
+			"timestamp": snap.Timestamp,
+			"notes":     snap.Notes,
+		}))
 		fmt.Printf("Restored snapshot from %s (notes: %s)\n", snap.Timestamp, snap.Notes)
 		return
 
+	case "snapshot-migrate":
+		files := []string{*file}
+		if *file == "" {
+			files, _ = filepath.Glob(filepath.Join(storage.SnapshotsDir(root), "*.json"))
+		}
+		failed := false
+		for _, f := range files {
+			from, err := storage.MigrateSnapshotFile(f)
+			switch {
+			case err != nil:
+				fmt.Printf("%s: %v\n", f, err)
+				failed = true
+			case from == storage.SnapshotSchema:
+				fmt.Printf("%s: already v%d\n", f, from)
+			default:
+				fmt.Printf("%s: migrated v%d -> v%d\n", f, from, storage.SnapshotSchema)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+
+	case "snapshot-list":
+		listSnapshots()
+		return
+
+	case "snapshot-diff":
+		if flag.NArg() != 2 {
+			log.Fatal("usage: -cmd snapshot-diff A.json B.json")
+		}
+		diffSnapshots(flag.Arg(0), flag.Arg(1))
+		return
+
+	case "snapshot-prune":
+		pruneSnapshots(storage.RetentionPolicy{KeepLast: *keepLast, KeepDaily: *keepDaily}, *dryRun)
+		return
+
+	case "policy-history":
+		policyHistory()
+		return
+
+	case "policy-revert":
+		policyRevert(*version)
+		return
+
+	case "proposal-add", "proposal-list", "proposal-approve", "proposal-reject", "proposal-rollback":
+		runProposal(*cmd, pf)
+		return
+
 	default:
-		ctx := context.Background()
-		logger := telemetry.NewLogger(".")
+		ctx, cancel := context.WithCancelCause(context.Background())
+		defer cancel(nil)
+		go cancelOnSignal(cancel)
+
+		logger := newLogger()
 		defer logger.Close()
 
-		ag := agent.NewAgent(logger)
+		ag := agent.Boot(logger, root, cfg)
+		if *input != "" {
+			p, err := perception.NewFile(*input, *follow)
+			if err != nil {
+				log.Fatalf("open input: %v", err)
+			}
+			defer p.Close()
+			ag.SetPerceiver(p)
+		}
 		if err := ag.Run(ctx); err != nil {
 			log.Fatalf("agent error: %v", err)
 		}
 	}
 }
+
+// cancelOnSignal cancels the agent's context on the first SIGINT or
+// SIGTERM so it can shut down cleanly. A second signal kills the process.
+func cancelOnSignal(cancel context.CancelCauseFunc) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	sig := <-sigs
+	signal.Stop(sigs)
+	cancel(fmt.Errorf("received %s", sig))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"neon/internal/agent"
+	"neon/pkg/structs"
+)
+
+// policyHistory prints every saved version of the rule set.
+func policyHistory() {
+	logger := newLogger()
+	defer logger.Close()
+	ag := agent.NewAgentWith(logger, root, cfg)
+
+	versions, err := ag.Policy().History()
+	if err != nil {
+		logger.Close()
+		log.Fatalf("policy history failed: %v", err)
+	}
+	if len(versions) == 0 {
+		fmt.Println("No policy history.")
+	}
+	for _, v := range versions {
+		fmt.Printf("v%-5d %s  rules=%-4d %s\n", v.N, v.Saved.Format(time.RFC3339), v.Rules, v.Path)
+	}
+}
+
+// policyRevert restores version n of the rule set. Run it while the agent
+// is stopped, or the running agent will overwrite it on its next save.
+func policyRevert(n int) {
+	if n <= 0 {
+		log.Fatal("must specify -version for policy-revert")
+	}
+	logger := newLogger()
+	defer logger.Close()
+	ag := agent.NewAgentWith(logger, root, cfg)
+
+	skipped, err := ag.Policy().Revert(n)
+	if err != nil {
+		logger.Close()
+		log.Fatalf("policy revert failed: %v", err)
+	}
+	for _, err := range skipped {
+		fmt.Printf("⚠ skipped invalid %v\n", err)
+	}
+	logger.Log(structs.NewEvent("REVERT", "operator", map[string]any{
+		"version": n,
+		"rules":   len(ag.Policy().Rules()),
+		"skipped": len(skipped),
+	}))
+	fmt.Printf("Policy reverted to version %d.\n", n)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"neon/internal/agent"
+	"neon/internal/policy"
+	"neon/internal/selfmod"
+	"neon/pkg/structs"
+)
+
+// proposalFlags carries the command-line fields used to build or decide
+// a self-modification proposal.
+type proposalFlags struct {
+	id        string
+	kind      string
+	word      string
+	mood      string
+	then      string
+	threshold float64
+	minCount  int
+	reason    string
+	notes     string
+}
+
+// runProposal handles the proposal-* commands. Apply and rollback operate
+// on the on-disk state, so run them while the agent is stopped.
+func runProposal(cmd string, f proposalFlags) {
+	queue := selfmod.NewQueue(root, clk)
+
+	switch cmd {
+	case "proposal-add":
+		p := &selfmod.Proposal{
+			Author:    "operator",
+			Reason:    f.reason,
+			Kind:      selfmod.Kind(f.kind),
+			Word:      f.word,
+			Threshold: f.threshold,
+			MinCount:  f.minCount,
+		}
+		if f.then != "" {
+			r := policy.Rule{Then: f.then}
+			r.When.Mood = f.mood
+			r.When.Word = f.word
+			p.Rule = &r
+		}
+		// Check the proposal against the current state now, so a bad one
+		// is turned away here rather than sitting in the queue until it
+		// fails on approval. Approval checks it again.
+		logger := newLogger()
+		err := selfmod.Validate(p, agent.NewAgentWith(logger, root, cfg))
+		logger.Close()
+		if err != nil {
+			log.Fatalf("proposal rejected: %v", err)
+		}
+		if err := queue.Submit(p); err != nil {
+			log.Fatalf("proposal add failed: %v", err)
+		}
+		fmt.Println("Proposal queued:", p.ID)
+
+	case "proposal-list":
+		ps, err := queue.List(selfmod.StatusPending)
+		if err != nil {
+			log.Fatalf("proposal list failed: %v", err)
+		}
+		if len(ps) == 0 {
+			fmt.Println("No pending proposals.")
+		}
+		for _, p := range ps {
+			fmt.Printf("%s  %s  %-14s word=%q by %s: %s\n",
+				p.ID, p.Created.Format(time.RFC3339), p.Kind, p.Word, p.Author, p.Reason)
+		}
+
+	case "proposal-approve", "proposal-reject", "proposal-rollback":
+		if f.id == "" {
+			log.Fatalf("must specify -id for %s", cmd)
+		}
+		p, err := queue.Get(f.id)
+		if err != nil {
+			log.Fatalf("%s failed: %v", cmd, err)
+		}
+
+		logger := newLogger()
+		defer logger.Close()
+		ag := agent.NewAgentWith(logger, root, cfg)
+
+		var etype string
+		switch cmd {
+		case "proposal-approve":
+			etype = "APPROVE"
+			err = selfmod.Apply(p, ag)
+		case "proposal-reject":
+			etype = "REJECT"
+			if p.Status != selfmod.StatusPending {
+				err = fmt.Errorf("proposal %s is %s, not pending", p.ID, p.Status)
+				break
+			}
+			p.Status = selfmod.StatusRejected
+			p.Decided = clk.Now().UTC()
+		case "proposal-rollback":
+			etype = "ROLLBACK"
+			err = selfmod.Rollback(p, ag)
+		}
+		if err != nil {
+			logger.Close()
+			log.Fatalf("%s failed: %v", cmd, err)
+		}
+		p.Note = f.notes
+		if err := queue.Put(p); err != nil {
+			logger.Close()
+			log.Fatalf("%s failed: %v", cmd, err)
+		}
+
+		logger.Log(structs.NewEvent(etype, "operator", map[string]any{
+			"id":       p.ID,
+			"kind":     p.Kind,
+			"word":     p.Word,
+			"status":   p.Status,
+			"snapshot": p.Snapshot,
+			"note":     p.Note,
+		}))
+		fmt.Printf("Proposal %s is now %s.\n", p.ID, p.Status)
+		if p.Snapshot != "" && p.Status == selfmod.StatusApplied {
+			fmt.Println("Rollback snapshot:", p.Snapshot)
+		}
+	}
+}
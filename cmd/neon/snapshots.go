@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"neon/internal/selfmod"
+	"neon/internal/storage"
+)
+
+// listSnapshots prints one line per snapshot, oldest first.
+func listSnapshots() {
+	infos, err := storage.ListSnapshots(root)
+	if err != nil {
+		log.Fatalf("snapshot list failed: %v", err)
+	}
+	if len(infos) == 0 {
+		fmt.Println("No snapshots.")
+		return
+	}
+	for _, s := range infos {
+		if s.Err != nil {
+			fmt.Printf("%s  (unreadable: %v)\n", s.Path, s.Err)
+			continue
+		}
+		fmt.Printf("%s  %s  %7dB  words=%-5d rules=%-4d %s\n",
+			filepath.Base(s.Path), s.Timestamp.Format(time.RFC3339), s.Size, s.Words, s.Rules, s.Notes)
+	}
+}
+
+// diffSnapshots prints how snapshot b differs from snapshot a.
+func diffSnapshots(a, b string) {
+	sa, err := storage.LoadSnapshot(a)
+	if err != nil {
+		log.Fatalf("snapshot diff failed: %v", err)
+	}
+	sb, err := storage.LoadSnapshot(b)
+	if err != nil {
+		log.Fatalf("snapshot diff failed: %v", err)
+	}
+	d := storage.DiffSnapshots(sa, sb)
+
+	fmt.Printf("A: %s (%s)\nB: %s (%s)\n", a, d.Meta["notes"][0], b, d.Meta["notes"][1])
+	fmt.Printf("words: +%d -%d ~%d\n", len(d.WordsAdded), len(d.WordsRemoved), len(d.WordsChanged))
+	if len(d.WordsAdded) > 0 {
+		fmt.Println("  added:  ", strings.Join(d.WordsAdded, ", "))
+	}
+	if len(d.WordsRemoved) > 0 {
+		fmt.Println("  removed:", strings.Join(d.WordsRemoved, ", "))
+	}
+	changed := make([]string, 0, len(d.WordsChanged))
+	for w := range d.WordsChanged {
+		changed = append(changed, w)
+	}
+	sort.Strings(changed)
+	for _, w := range changed {
+		c := d.WordsChanged[w]
+		fmt.Printf("  %s: %d -> %d\n", w, c[0], c[1])
+	}
+
+	fmt.Printf("rules: +%d -%d ~%d\n", len(d.RulesAdded), len(d.RulesRemoved), len(d.RulesChanged))
+	for _, r := range d.RulesAdded {
+		fmt.Println("  +", r)
+	}
+	for _, r := range d.RulesRemoved {
+		fmt.Println("  -", r)
+	}
+	for _, r := range d.RulesChanged {
+		fmt.Printf("  ~ %s\n    -> %s\n", r[0], r[1])
+	}
+
+	fields := make([]string, 0, len(d.MoodChanged))
+	for k := range d.MoodChanged {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	fmt.Printf("mood: %d fields changed\n", len(fields))
+	for _, k := range fields {
+		fmt.Printf("  %s: %v -> %v\n", k, d.MoodChanged[k][0], d.MoodChanged[k][1])
+	}
+	if len(d.SeenAdded) > 0 {
+		fmt.Printf("seen: +%d\n", len(d.SeenAdded))
+	}
+}
+
+// pruneSnapshots applies the retention policy, sparing any snapshot an
+// applied proposal may still need for rollback. Days are counted back
+// from clk, so a seeded agent's snapshots, dated on its simulated clock,
+// are judged on that clock too.
+func pruneSnapshots(policy storage.RetentionPolicy, dryRun bool) {
+	if policy.KeepLast <= 0 && policy.KeepDaily <= 0 {
+		log.Fatal("refusing to prune without -keep-last or -keep-daily")
+	}
+
+	protect := make(map[string]bool)
+	applied, err := selfmod.NewQueue(root, clk).List(selfmod.StatusApplied)
+	if err != nil {
+		log.Fatalf("snapshot prune failed: %v", err)
+	}
+	for _, p := range applied {
+		if p.Snapshot != "" {
+			protect[filepath.Clean(p.Snapshot)] = true
+		}
+	}
+
+	removed, err := storage.PruneSnapshots(root, policy, protect, clk.Now(), dryRun)
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	for _, p := range removed {
+		fmt.Println(verb, p)
+	}
+	if err != nil {
+		log.Fatalf("snapshot prune failed: %v", err)
+	}
+	fmt.Printf("%s %d snapshots.\n", verb, len(removed))
+}
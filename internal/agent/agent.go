@@ -1,175 +1,504 @@
 package agent
 
 import (
-	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
+	"neon/internal/clock"
 	"neon/internal/cognition"
+	"neon/internal/config"
+	"neon/internal/memory"
+	"neon/internal/perception"
 	"neon/internal/persona"
 	"neon/internal/policy"
+	"neon/internal/reflect"
 	"neon/internal/storage"
 	"neon/internal/telemetry"
+	"neon/internal/world"
 	"neon/pkg/structs"
 )
 
 type Agent struct {
+	root      string
+	cfg       config.Config
+	clock     clock.Clock
+	recovered recovery // what Boot repaired
 	logger    *telemetry.Logger
 	mood      *persona.Engine
+	moodPath  string
 	weights   *storage.Weights
 	path      string
 	cognition *cognition.Engine
+	seenPath  string
 	policy    *policy.Engine
+	memory    *memory.Store
+	world     *world.Model
+	reflector *reflect.Job
+	curiosity *policy.Curiosity
+	last      decision // how the previous response was chosen, for :why
+
+	// Rule garbage collection
+	gc      policy.GCPolicy
+	gcEvery int // run rule GC every N turns (0 disables)
+
+	// Guardrail violations logged recently, by guardrail and word
+	violations map[string]guardrailLog
+
+	// Automatic snapshots
+	snapshots SnapshotPolicy
+	turns     int
+	lastSnap  time.Time
+	mutating  bool // previous turn changed the policy
+	out       io.Writer
+	perceiver perception.Perceiver
 }
 
+// NewAgent creates an agent with the default configuration whose state
+// lives under the working directory.
 func NewAgent(logger *telemetry.Logger) *Agent {
+	return NewAgentWith(logger, ".", config.Default())
+}
+
+// NewAgentWith creates an agent under root tuned by cfg, which the caller
+// should already have validated. The agent runs on the logger's clock.
+func NewAgentWith(logger *telemetry.Logger, root string, cfg config.Config) *Agent {
+	clk := logger.Clock()
+	weightsPath := config.Path(root, cfg.Paths.Weights)
+	policyPath := config.Path(root, cfg.Paths.Policy)
+
 	// Word weights (beliefs)
 	weights := storage.NewWeights()
-	weightsPath := filepath.Join("data", "beliefs", "weights.json")
 	_ = os.MkdirAll(filepath.Dir(weightsPath), 0o755)
 	_ = weights.Load(weightsPath)
 
 	// Policy rules
-	policyPath := filepath.Join("data", "policy", "policy.json")
 	_ = os.MkdirAll(filepath.Dir(policyPath), 0o755)
-	policies := policy.NewEngine(policyPath)
+	policies := policy.NewEngine(policyPath, clk)
+	policies.SetGuardrails(policy.Guardrails{
+		MaxRules:          cfg.Policy.MaxRules,
+		MaxEditsPerMinute: cfg.Policy.MaxEditsPerMinute,
+		Deny:              cfg.Policy.Deny,
+	})
+
+	// Episodic memory
+	episodes, _ := memory.NewStore(config.Path(root, cfg.Paths.Episodes), clk)
+	seed := cfg.Agent.Seed
+	if seed == 0 {
+		seed = clk.Now().UnixNano()
+	}
+	cog := cognition.NewEngine(weights, cognition.Options{EmotionThreshold: cfg.Cognition.EmotionThreshold}, rand.New(rand.NewSource(seed)))
+	cog.SetMemory(episodes)
+	seenPath := config.Path(root, cfg.Paths.Seen)
+	_ = os.MkdirAll(filepath.Dir(seenPath), 0o755)
+	_ = cog.Load(seenPath)
+
+	// Mood state
+	mood := persona.NewEngine(moodOptions(cfg.Mood), clk)
+	moodPath := config.Path(root, cfg.Paths.Mood)
+	_ = os.MkdirAll(filepath.Dir(moodPath), 0o755)
+	_ = mood.Load(moodPath)
+	if cfg.Paths.Lexicon != "" {
+		if lex, err := persona.LoadLexicon(config.Path(root, cfg.Paths.Lexicon)); err == nil {
+			mood.SetLexicon(lex)
+		}
+	}
+
+	// Predictive world model
+	worldPath := config.Path(root, cfg.Paths.World)
+	_ = os.MkdirAll(filepath.Dir(worldPath), 0o755)
+	model, _ := world.NewModel(worldPath, cfg.Agent.WorldLearningRate)
 
 	return &Agent{
+		root:      root,
+		cfg:       cfg,
+		clock:     clk,
 		logger:    logger,
-		mood:      persona.NewEngine(0.05),
+		mood:      mood,
+		moodPath:  moodPath,
 		weights:   weights,
 		path:      weightsPath,
-		cognition: cognition.NewEngine(weights),
+		cognition: cog,
+		seenPath:  seenPath,
 		policy:    policies,
+		memory:    episodes,
+		world:     model,
+		reflector: reflect.NewJob(root, logger, cfg.Reflection.EveryTurns, time.Duration(cfg.Reflection.Every)),
+		curiosity: policy.NewCuriosity(cfg.Policy.CuriosityThreshold),
+		snapshots: SnapshotPolicy{
+			EveryTurns:     cfg.Snapshots.EveryTurns,
+			Every:          time.Duration(cfg.Snapshots.Every),
+			BeforeMutation: cfg.Snapshots.BeforeMutation,
+			OnExit:         cfg.Snapshots.OnExit,
+		},
+		gc: policy.GCPolicy{
+			MinAge: time.Duration(cfg.Policy.GCMinAge),
+			Idle:   time.Duration(cfg.Policy.GCIdle),
+		},
+		gcEvery:   cfg.Policy.GCEvery,
+		lastSnap:  clk.Now(),
+		out:       os.Stdout,
+		perceiver: perception.NewConsole(os.Stdin, os.Stdout),
 	}
 }
 
-func (a *Agent) Run(ctx context.Context) error {
-	reader := bufio.NewReader(os.Stdin)
+// moodOptions translates the mood configuration for the persona package.
+func moodOptions(m config.Mood) persona.Options {
+	return persona.Options{
+		Decay:     m.Decay,
+		Threshold: m.Threshold,
+		Clamp:     m.Clamp,
+		Decays: map[string]float64{
+			persona.Arousal:     m.ArousalDecay,
+			persona.Dominance:   m.DominanceDecay,
+			persona.Curiosity:   m.CuriosityDecay,
+			persona.Frustration: m.FrustrationDecay,
+			persona.Calm:        m.CalmDecay,
+		},
+	}
+}
 
+// SetOutput redirects console output (responses, notices) to w.
+func (a *Agent) SetOutput(w io.Writer) {
+	a.out = w
+}
+
+// SetPerceiver replaces the input source (console on stdin by default).
+func (a *Agent) SetPerceiver(p perception.Perceiver) {
+	a.perceiver = p
+}
+
+// Run drives the agent from its perceiver until the user types "exit",
+// the input is exhausted or ctx is cancelled. Each of these shuts the
+// agent down cleanly, persisting its state, and Run returns nil.
+func (a *Agent) Run(ctx context.Context) error {
 	a.logger.Log(structs.NewEvent("BOOT", "system", map[string]any{
 		"message": "NEON boot sequence",
+		"config":  a.cfg,
 	}))
 
-	fmt.Println("Type something (or 'exit' to quit):")
+	a.logRecovery()
+	a.loadFailed(a.cfg.Paths.Policy, a.policy.Err(), a.policy.Skipped())
+
+	a.reflector.Start(ctx)
+
+	// Time-based snapshots are taken here, between turns, so an idle
+	// agent still takes them.
+	var snapTimer *time.Timer
+	var snapDue <-chan time.Time
+	if a.snapshots.Every > 0 {
+		snapTimer = time.NewTimer(a.snapshots.Every)
+		defer snapTimer.Stop()
+		snapDue = snapTimer.C
+	}
+
+	fmt.Fprintln(a.out, "Type something (or 'exit' to quit):")
 
+	next := a.next(ctx)
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			fmt.Print(">> ")
-			line, err := reader.ReadString('\n')
+			fmt.Fprintln(a.out)
+			a.shutdown("system", "interrupted", cancelMessage(ctx))
+			return nil
+
+		case <-snapDue:
+			// A snapshot taken since the timer was set pushes this one back.
+			if wait := a.snapshots.Every - a.clock.Now().Sub(a.lastSnap); wait > 0 {
+				snapTimer.Reset(wait)
+				continue
+			}
+			a.autoSnapshot(fmt.Sprintf("every %s", a.snapshots.Every))
+			snapTimer.Reset(a.snapshots.Every)
+
+		case r := <-next:
+			p, err := r.percept, r.err
+			if err == io.EOF {
+				a.shutdown(p.Source, "eof", "Input exhausted")
+				return nil
+			}
+			if ctx.Err() != nil {
+				fmt.Fprintln(a.out)
+				a.shutdown("system", "interrupted", cancelMessage(ctx))
+				return nil
+			}
 			if err != nil {
 				return err
 			}
-			line = strings.TrimSpace(line)
 
 			// Exit condition
-			if line == "exit" {
-				a.logger.Log(structs.NewEvent("EXIT", "console", map[string]any{
-					"message": "User requested shutdown",
-				}))
-
-				// Save beliefs
-				if err := a.weights.Save(a.path); err != nil {
-					fmt.Println("⚠ failed to save weights:", err)
-				}
-				// Save policy rules
-				if err := a.policy.Save(); err != nil {
-					fmt.Println("⚠ failed to save policy:", err)
-				}
-
-				fmt.Println("Goodbye.")
-				health := a.logger.Health()
-				fmt.Printf("Health summary: uptime=%ds, events=%d, errors=%d\n",
-					health["uptime_sec"], health["events"], health["errors"])
+			if p.Text == "exit" {
+				a.shutdown(p.Source, "user", "User requested shutdown")
 				return nil
 			}
 
-			// Update mood
-			curMood, curScore := a.mood.UpdateFromText(line)
-
-			// Update weights
-			a.weights.Update(line)
-			_ = a.weights.Save(a.path)
-
-			// Check for new/high-frequency words and propose/edit rules
-			for _, wc := range a.weights.TopN(3) {
-				if !a.policy.HasRuleFor(wc.Word) {
-					// New word → propose new rule
-					r := policy.Rule{}
-					r.When.Mood = string(curMood)
-					r.When.Word = wc.Word
-					r.Then = fmt.Sprintf("I noticed the word '%s'.", wc.Word)
-
-					a.policy.AddRule(r)
-					_ = a.policy.Save()
-
-					a.logger.Log(structs.NewEvent("PROPOSE", "agent", map[string]any{
-						"word":  wc.Word,
-						"mood":  curMood,
-						"rule":  r,
-						"count": wc.Count,
-					}))
-
-					fmt.Printf("(%s) I created a new rule for '%s'.\n", curMood, wc.Word)
-
-				} else {
-					// Existing rule → maybe edit if mood context has shifted
-					newText := fmt.Sprintf("Now I feel %s about '%s'.", curMood, wc.Word)
-					if a.policy.UpdateRule(wc.Word, newText) {
-						_ = a.policy.Save()
-						a.logger.Log(structs.NewEvent("EDIT", "agent", map[string]any{
-							"word":  wc.Word,
-							"mood":  curMood,
-							"text":  newText,
-							"count": wc.Count,
-						}))
-						fmt.Printf("(%s) I updated my rule for '%s'.\n", curMood, wc.Word)
-					}
-				}
+			if !a.command(p) {
+				a.Step(p)
+				time.Sleep(30 * time.Millisecond)
 			}
+			next = a.next(ctx)
+		}
+	}
+}
 
-			// Log INPUT
-			a.logger.Log(structs.NewEvent("INPUT", "console", map[string]any{
-				"text":       line,
-				"mood_now":   string(curMood),
-				"mood_score": curScore,
-				"top_words":  a.weights.TopN(5),
-			}))
+// perceived is the result of one call to the perceiver's Next.
+type perceived struct {
+	percept perception.Percept
+	err     error
+}
+
+// next reads the next percept in the background, so Run can do other
+// work while it waits. Only one read is outstanding at a time; turns
+// themselves still run on Run's goroutine.
+func (a *Agent) next(ctx context.Context) <-chan perceived {
+	ch := make(chan perceived, 1)
+	go func() {
+		p, err := a.perceiver.Next(ctx)
+		ch <- perceived{p, err}
+	}()
+	return ch
+}
+
+// cancelMessage describes why ctx was cancelled.
+func cancelMessage(ctx context.Context) string {
+	if cause := context.Cause(ctx); cause != nil && cause != ctx.Err() {
+		return "Shutdown: " + cause.Error()
+	}
+	return "Context cancelled"
+}
+
+// shutdown persists beliefs, seen words, policy, mood and the world model,
+// takes a final snapshot, then logs the EXIT event with reason and flushes
+// the logger.
+func (a *Agent) shutdown(source, reason, message string) {
+	if source == "" {
+		source = "system"
+	}
+
+	a.saveFailed("weights", a.weights.Save(a.path))
+	a.saveFailed("seen words", a.cognition.Save(a.seenPath))
+	a.saveFailed("policy", a.policy.Save())
+	a.saveFailed("mood", a.mood.Save(a.moodPath))
+	a.saveFailed("world model", a.world.Save())
+
+	if a.snapshots.OnExit {
+		a.autoSnapshot("exit: " + message)
+	}
+
+	a.logger.Log(structs.NewEvent("EXIT", source, map[string]any{
+		"reason":  reason,
+		"message": message,
+	}))
+	a.logger.Flush()
+
+	fmt.Fprintln(a.out, "Goodbye.")
+	health := a.logger.Health()
+	fmt.Fprintf(a.out, "Health summary: uptime=%ds, events=%d, errors=%d\n",
+		health["uptime_sec"], health["events"], health["errors"])
+}
+
+// traceMisses is how many of the rules that didn't match are kept in the
+// trace logged with each OUTPUT event.
+const traceMisses = 5
+
+// Step processes a single percept: it updates mood and beliefs, proposes
+// or edits rules, produces a response and maybe reflects.
+// All resulting events are sent to the logger.
+func (a *Agent) Step(p perception.Percept) {
+	line := p.Text
+	a.turns++
+	a.clock.Tick()
+
+	// Periodic snapshots, taken before this turn changes anything.
+	// Time-based ones are taken by Run.
+	if a.snapshots.EveryTurns > 0 && a.turns%a.snapshots.EveryTurns == 0 {
+		a.autoSnapshot(fmt.Sprintf("every %d turns", a.snapshots.EveryTurns))
+	}
+
+	// Update mood
+	curMood, curScore := a.mood.UpdateFromText(line)
+
+	// Compare with what we expected this turn to look like
+	surpriseScore := 0.0
+	if surprise := a.world.Observe(line, curScore); surprise != nil {
+		surpriseScore = surprise.Score
+		a.logger.Log(structs.NewEvent("SURPRISE", "world", map[string]any{
+			"expected_delta":  surprise.Expected.MoodDelta,
+			"actual_delta":    surprise.ActualDelta,
+			"mood_error":      surprise.MoodError,
+			"expected_topics": surprise.Expected.Topics,
+			"actual_topics":   surprise.ActualTopics,
+			"topic_recall":    surprise.TopicRecall,
+			"surprise_score":  surprise.Score,
+		}))
+	}
+
+	// How curious are we? Judged before this input becomes familiar.
+	curious := a.curiosity.Evaluate(line, func(w string) bool {
+		return a.weights.Count(w) > 0 || a.cognition.Seen(w)
+	}, surpriseScore)
+	// Novelty feeds curiosity and familiarity dulls it.
+	a.mood.Nudge(persona.Curiosity, 0.2*(curious.Score-0.5))
+	affect := a.mood.Affect()
+
+	// Update weights
+	a.weights.Update(line)
+	a.saveFailed("weights", a.weights.Save(a.path))
+	a.saveFailed("mood", a.mood.Save(a.moodPath))
+
+	// Check for new/high-frequency words and propose/edit rules,
+	// within the policy's guardrails. Responses are templates, so the word
+	// goes in as {{.Word}} rather than as text that could hold template code.
+	mutating := false
+	for _, wc := range a.weights.TopN(a.cfg.Agent.RuleTop) {
+		existing, has := a.policy.RuleFor(wc.Word)
+		newText := fmt.Sprintf("Now I feel %s about '{{.Word}}'.", curMood)
+		if has && existing.Then == newText {
+			continue
+		}
+		// Words the guardrails rule out are skipped without trying
+		action := "add"
+		if has {
+			action = "edit"
+		}
+		if err := a.policy.CheckAgentChange(wc.Word); err != nil {
+			a.guardrail(err, action)
+			continue
+		}
 
-			// Generate cognition-based response
-			resp := a.cognition.Respond(line, curMood)
+		// Snapshot once at the start of a burst of rule changes
+		if !mutating && !a.mutating && a.snapshots.BeforeMutation {
+			a.autoSnapshot("before policy mutation")
+		}
+		mutating = true
+
+		if !has {
+			// New word → propose new rule
+			r := policy.Rule{}
+			r.When.Mood = string(curMood)
+			r.When.Word = wc.Word
+			r.Then = "I noticed the word '{{.Word}}'."
+			r.Stats = &policy.Stats{Created: a.clock.Now().UTC()}
 
-			// Apply policy override if matched
-			if override := a.policy.Apply(curMood, line); override != "" {
-				resp = fmt.Sprintf("(%s) %s", curMood, override)
+			if err := a.policy.AddAgentRule(r); err != nil {
+				if !a.guardrail(err, action) {
+					fmt.Fprintln(a.out, "⚠ failed to add rule:", err)
+				}
+				continue
 			}
+			a.saveFailed("policy", a.policy.Save())
 
-			// Output & log
-			fmt.Println(resp)
-			a.logger.Log(structs.NewEvent("OUTPUT", "agent", map[string]any{
-				"text":       resp,
-				"mood_now":   string(curMood),
-				"mood_score": curScore,
+			a.logger.Log(structs.NewEvent("PROPOSE", "agent", map[string]any{
+				"word":  wc.Word,
+				"mood":  curMood,
+				"rule":  r,
+				"count": wc.Count,
 			}))
 
-			// Maybe reflect
-			if refl := a.cognition.ReflectIfNeeded(line, curMood, curScore); refl != "" {
-				fmt.Println(refl)
-				a.logger.Log(structs.NewEvent("REFLECT", "agent", map[string]any{
-					"text":       refl,
-					"mood_now":   string(curMood),
-					"mood_score": curScore,
+			fmt.Fprintf(a.out, "(%s) I created a new rule for '%s'.\n", curMood, wc.Word)
+
+		} else {
+			// Existing rule → maybe edit if mood context has shifted
+			changed, err := a.policy.EditAgentRule(wc.Word, newText, a.clock.Now().UTC())
+			if err != nil {
+				if !a.guardrail(err, action) {
+					fmt.Fprintln(a.out, "⚠ failed to edit rule:", err)
+				}
+				continue
+			}
+			if changed {
+				a.saveFailed("policy", a.policy.Save())
+				a.logger.Log(structs.NewEvent("EDIT", "agent", map[string]any{
+					"word":  wc.Word,
+					"mood":  curMood,
+					"text":  newText,
+					"count": wc.Count,
 				}))
+				fmt.Fprintf(a.out, "(%s) I updated my rule for '%s'.\n", curMood, wc.Word)
 			}
+		}
+	}
+
+	a.mutating = mutating
+
+	// Log INPUT
+	a.logger.Log(structs.NewEvent("INPUT", p.Source, map[string]any{
+		"text":       line,
+		"mood_now":   string(curMood),
+		"mood_score": curScore,
+		"top_words":  a.weights.TopN(a.cfg.Agent.InputTop),
+	}))
 
-			time.Sleep(30 * time.Millisecond)
+	// Generate cognition-based response
+	resp := a.cognition.Respond(line, curMood, affect)
+
+	// Apply policy override if matched
+	// (unless curious enough to ask about what we don't know instead)
+	var fired []string
+	var trace *policy.Trace
+	in := policy.Input{Mood: curMood, Score: curScore, Affect: affect, Text: line, Top: topWords(a.weights.TopN(a.cfg.Agent.InputTop)), Turn: a.turns}
+	if q := a.curiosity.Question(curMood, curious); q != "" {
+		resp = q
+	} else if r, t, ok := a.policy.Fire(in, a.clock.Now().UTC()); ok && r.Then != "" {
+		trace = &t
+		text, err := a.policy.Render(r, in)
+		if err != nil {
+			fmt.Fprintln(a.out, "⚠ failed to render rule:", err)
 		}
+		resp = fmt.Sprintf("(%s) %s", curMood, text)
+		fired = append(fired, r.When.Word)
+		a.saveFailed("policy", a.policy.Save())
+	} else {
+		trace = &t
+	}
+	a.last = decision{input: line, trace: trace, curiosity: curious}
+
+	// Output & log. The logged trace keeps the rules that matched and the
+	// closest misses; the full one is kept for :why.
+	var logged *policy.Trace
+	if trace != nil {
+		t := trace.Trim(traceMisses)
+		logged = &t
+	}
+	fmt.Fprintln(a.out, resp)
+	a.logger.Log(structs.NewEvent("OUTPUT", "agent", map[string]any{
+		"text":       resp,
+		"mood_now":   string(curMood),
+		"mood_score": curScore,
+		"affect":     affect,
+		"rules":      fired,
+		"trace":      logged,
+		"curiosity":  curious,
+	}))
+
+	// Remember this turn
+	if err := a.memory.Record(memory.Episode{
+		Timestamp: a.clock.Now().UTC(),
+		Input:     line,
+		Mood:      curMood,
+		Score:     curScore,
+		Response:  resp,
+		Rules:     fired,
+	}); err != nil {
+		fmt.Fprintln(a.out, "⚠ failed to record episode:", err)
+	}
+
+	a.cognition.Notice(line)
+	a.saveFailed("seen words", a.cognition.Save(a.seenPath))
+
+	// Retire rules that have stopped earning their keep
+	if a.gcEvery > 0 && a.turns%a.gcEvery == 0 {
+		a.retireRules()
+	}
+
+	// Scheduled reflection on recent activity
+	if report, err := a.reflector.Tick(); err != nil {
+		fmt.Fprintln(a.out, "⚠ reflection failed:", err)
+	} else if report != nil {
+		fmt.Fprintf(a.out, "(%s) %s\n", curMood, report.Summary)
 	}
 }
@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"neon/pkg/structs"
+)
+
+// SnapshotPolicy controls when the agent snapshots itself automatically.
+// Zero fields disable the corresponding trigger.
+type SnapshotPolicy struct {
+	EveryTurns     int           // every N turns
+	Every          time.Duration // at most this long between snapshots
+	BeforeMutation bool          // before the first turn of a burst of rule changes
+	OnExit         bool          // on clean shutdown
+}
+
+// autoSnapshot snapshots the agent, recording why in the snapshot notes.
+// The world model isn't part of snapshots, so it is saved alongside.
+func (a *Agent) autoSnapshot(reason string) {
+	a.lastSnap = a.clock.Now()
+	a.saveFailed("world model", a.world.Save())
+	path, err := a.Snapshot("auto: " + reason)
+	if err != nil {
+		fmt.Fprintln(a.out, "⚠ failed to snapshot:", err)
+		return
+	}
+	a.logger.Log(structs.NewEvent("SNAPSHOT", "agent", map[string]any{
+		"path":   path,
+		"reason": reason,
+	}))
+}
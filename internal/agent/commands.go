@@ -0,0 +1,271 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"neon/internal/perception"
+	"neon/internal/persona"
+	"neon/internal/policy"
+	"neon/internal/storage"
+	"neon/pkg/structs"
+)
+
+// commandHelp lists the console commands.
+const commandHelp = `Commands:
+  :mood                  show the current mood and affect
+  :top [N]               show the N most frequent words (default 10)
+  :rules                 list the policy rules
+  :rule add WORD TEXT    add a rule answering TEXT when WORD is seen
+  :rule del WORD         delete the rule for WORD
+  :forget WORD           forget a word
+  :snapshot [NOTE]       snapshot the agent
+  :restore FILE          restore a snapshot (FILE is relative to data/snapshots)
+  :health                show logger health counters
+  :reflect               reflect on recent activity now
+  :why                   explain the last response
+  :help                  show this list`
+
+// commands are the names command handles.
+var commands = map[string]bool{
+	":mood": true, ":top": true, ":rules": true, ":rule": true,
+	":forget": true, ":snapshot": true, ":restore": true, ":health": true,
+	":reflect": true, ":why": true, ":help": true,
+}
+
+// decision records how the agent chose its last response.
+type decision struct {
+	input     string
+	trace     *policy.Trace // nil if the policy wasn't consulted
+	curiosity policy.CuriosityScore
+}
+
+// command handles console commands: lines starting with one of the
+// names in commands. It reports whether p was a command, in which case it
+// is not treated as a turn; anything else, such as ":)", is ordinary
+// input. Every command is logged as a COMMAND event.
+func (a *Agent) command(p perception.Percept) bool {
+	fields := strings.Fields(p.Text)
+	if len(fields) == 0 || !commands[fields[0]] {
+		return false
+	}
+	name, args := fields[0], fields[1:]
+
+	err := a.runCommand(name, args, p.Source)
+	if err != nil {
+		fmt.Fprintf(a.out, "⚠ %s: %v\n", name, err)
+	}
+	payload := map[string]any{
+		"command": name,
+		"args":    args,
+		"ok":      err == nil,
+	}
+	if err != nil {
+		payload["error"] = err.Error()
+	}
+	a.logger.Log(structs.NewEvent("COMMAND", p.Source, payload))
+	return true
+}
+
+func (a *Agent) runCommand(name string, args []string, source string) error {
+	switch name {
+	case ":help":
+		fmt.Fprintln(a.out, commandHelp)
+
+	case ":mood":
+		mood, score := a.mood.Get()
+		fmt.Fprintf(a.out, "Mood %s (score %.2f, threshold %.2f).\n", mood, score, a.mood.Threshold())
+		affect := a.mood.Affect()
+		for _, d := range persona.Dimensions {
+			fmt.Fprintf(a.out, "  %-12s %6.2f\n", d, affect[d])
+		}
+
+	case ":top":
+		n := 10
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n <= 0 {
+				return fmt.Errorf("bad count %q", args[0])
+			}
+		}
+		top := a.weights.TopN(n)
+		if len(top) == 0 {
+			fmt.Fprintln(a.out, "I don't know any words yet.")
+		}
+		for i, wc := range top {
+			fmt.Fprintf(a.out, "%3d. %-20s %d\n", i+1, wc.Word, wc.Count)
+		}
+
+	case ":rules":
+		rules := a.policy.Rules()
+		if len(rules) == 0 {
+			fmt.Fprintln(a.out, "I have no rules.")
+		}
+		for i, r := range rules {
+			when, _ := json.Marshal(r.When)
+			hits := 0
+			if r.Stats != nil {
+				hits = r.Stats.Hits
+			}
+			author := r.Author
+			if author == "" {
+				author = policy.AgentAuthor
+			}
+			fmt.Fprintf(a.out, "#%d %s → %q (by %s, %d hits", i, when, r.Then, author, hits)
+			if r.Priority != 0 {
+				fmt.Fprintf(a.out, ", priority %d", r.Priority)
+			}
+			if r.Guarded() {
+				fmt.Fprint(a.out, ", protected")
+			}
+			fmt.Fprintln(a.out, ")")
+		}
+
+	case ":rule":
+		return a.ruleCommand(args)
+
+	case ":forget":
+		if len(args) != 1 {
+			return errors.New("usage: :forget WORD")
+		}
+		word := strings.ToLower(args[0])
+		removed := a.weights.Prune(word, 0)
+		seen := a.cognition.SeenWords()
+		kept := seen[:0]
+		for _, w := range seen {
+			if w != word {
+				kept = append(kept, w)
+			}
+		}
+		a.cognition.SetSeen(kept)
+		if err := errors.Join(a.weights.Save(a.path), a.cognition.Save(a.seenPath)); err != nil {
+			return err
+		}
+		if removed == 0 && len(kept) == len(seen) {
+			fmt.Fprintf(a.out, "I didn't know '%s'.\n", word)
+		} else {
+			fmt.Fprintf(a.out, "I forgot '%s'.\n", word)
+		}
+
+	case ":snapshot":
+		notes := strings.Join(args, " ")
+		path, err := a.Snapshot(notes)
+		if err != nil {
+			return err
+		}
+		a.logger.Log(structs.NewEvent("SNAPSHOT", source, map[string]any{
+			"path":  path,
+			"notes": notes,
+		}))
+		fmt.Fprintln(a.out, "Snapshot saved to", path)
+
+	case ":restore":
+		if len(args) != 1 {
+			return errors.New("usage: :restore FILE")
+		}
+		// Relative names are looked up among the agent's snapshots, not
+		// in the working directory, which needn't be the agent's root.
+		path := args[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(storage.SnapshotsDir(a.root), path)
+		}
+		snap, err := storage.LoadSnapshot(path)
+		if err != nil {
+			return err
+		}
+		if err := a.Restore(snap); err != nil {
+			return err
+		}
+		a.logger.Log(structs.NewEvent("RESTORE", source, map[string]any{
+			"path":      path,
+			"timestamp": snap.Timestamp,
+			"notes":     snap.Notes,
+		}))
+		fmt.Fprintf(a.out, "Restored snapshot from %s (notes: %s)\n", snap.Timestamp, snap.Notes)
+
+	case ":health":
+		health := a.logger.Health()
+		fmt.Fprintf(a.out, "Health: uptime=%ds, events=%d, errors=%d, turns=%d\n",
+			health["uptime_sec"], health["events"], health["errors"], a.turns)
+
+	case ":reflect":
+		report, err := a.reflector.Run("command")
+		if err != nil {
+			return err
+		}
+		if report == nil {
+			fmt.Fprintln(a.out, "Nothing new to reflect on.")
+			break
+		}
+		fmt.Fprintln(a.out, report.Summary)
+
+	case ":why":
+		a.why()
+
+	default:
+		return fmt.Errorf("unknown command (try :help)")
+	}
+	return nil
+}
+
+// ruleCommand handles ":rule add WORD TEXT" and ":rule del WORD". Rules
+// added here are operator-authored, so the agent won't edit or retire them.
+func (a *Agent) ruleCommand(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: :rule add WORD TEXT | :rule del WORD")
+	}
+	word := strings.ToLower(args[1])
+
+	switch args[0] {
+	case "add":
+		if len(args) < 3 {
+			return errors.New("usage: :rule add WORD TEXT")
+		}
+		if err := a.policy.CheckTrigger(word); err != nil {
+			return err
+		}
+		if a.policy.HasRuleFor(word) {
+			return fmt.Errorf("a rule for '%s' already exists", word)
+		}
+		r := policy.Rule{Then: strings.Join(args[2:], " "), Author: "operator",
+			Stats: &policy.Stats{Created: a.clock.Now().UTC()}}
+		r.When.Word = word
+		if err := a.policy.AddRule(r); err != nil {
+			return err
+		}
+		if err := a.policy.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Added a rule for '%s'.\n", word)
+
+	case "del":
+		if !a.policy.DeleteRule(word) {
+			return fmt.Errorf("no rule for '%s'", word)
+		}
+		if err := a.policy.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "Deleted the rule for '%s'.\n", word)
+
+	default:
+		return fmt.Errorf("unknown rule action %q", args[0])
+	}
+	return nil
+}
+
+// why explains the previous response.
+func (a *Agent) why() {
+	switch {
+	case a.last.input == "":
+		fmt.Fprintln(a.out, "I haven't responded to anything yet.")
+	case a.last.trace == nil:
+		fmt.Fprintf(a.out, "For %q I asked a question instead of consulting my rules: curiosity %.2f (novelty %.2f, surprise %.2f).\n",
+			a.last.input, a.last.curiosity.Score, a.last.curiosity.Novelty, a.last.curiosity.Surprise)
+	default:
+		fmt.Fprintf(a.out, "For %q: %s\n", a.last.input, a.last.trace)
+	}
+}
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"neon/internal/policy"
+	"neon/pkg/structs"
+)
+
+// retireRules runs a GC pass over the policy and logs a RETIRE event for
+// each rule removed.
+func (a *Agent) retireRules() {
+	top, now := topWords(a.weights.TopN(a.cfg.Policy.GCTop)), a.clock.Now().UTC()
+	if len(a.policy.GC(a.gc, top, now, true)) == 0 {
+		return
+	}
+	if a.snapshots.BeforeMutation {
+		a.autoSnapshot("before rule retirement")
+	}
+	retired := a.policy.GC(a.gc, top, now, false)
+	a.saveFailed("policy", a.policy.Save())
+	for _, rt := range retired {
+		a.logger.Log(structs.NewEvent("RETIRE", "agent", map[string]any{
+			"word":   rt.Rule.When.Word,
+			"rule":   rt.Rule,
+			"reason": rt.Reason,
+		}))
+	}
+}
+
+// guardrailQuiet is how long a guardrail stays quiet about a word after
+// logging it, so a word that stays among the top words doesn't flood the
+// log every turn while a later rejection is still logged.
+const guardrailQuiet = time.Minute
+
+// guardrailLog is when a guardrail last logged a word, and how many
+// rejections of it have been suppressed since.
+type guardrailLog struct {
+	at         time.Time
+	suppressed int
+}
+
+// guardrail logs a GUARDRAIL event if err is a guardrail violation and
+// reports whether it was. Repeats of the same guardrail and word within
+// guardrailQuiet are counted rather than logged; the next event for them
+// carries the count.
+func (a *Agent) guardrail(err error, action string) bool {
+	var v *policy.Violation
+	if !errors.As(err, &v) {
+		return false
+	}
+	now := a.clock.Now()
+	key := v.Guardrail + ":" + strings.ToLower(v.Word)
+	last, seen := a.violations[key]
+	if seen && now.Sub(last.at) < guardrailQuiet {
+		last.suppressed++
+		a.violations[key] = last
+		return true
+	}
+	if a.violations == nil {
+		a.violations = make(map[string]guardrailLog)
+	}
+	a.violations[key] = guardrailLog{at: now}
+	a.logger.Log(structs.NewEvent("GUARDRAIL", "policy", map[string]any{
+		"guardrail":  v.Guardrail,
+		"action":     action,
+		"word":       v.Word,
+		"detail":     v.Detail,
+		"suppressed": last.suppressed,
+	}))
+	return true
+}
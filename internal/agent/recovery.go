@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"neon/internal/config"
+	"neon/internal/policy"
+	"neon/internal/storage"
+	"neon/internal/telemetry"
+	"neon/pkg/structs"
+)
+
+// recovery is what recoverState did, reported as a RECOVERY event once the
+// agent has booted.
+type recovery struct {
+	actions       []string
+	weightsFailed bool
+	policyFailed  bool
+}
+
+// Boot creates an agent for a run, like NewAgentWith, after repairing the
+// state a crash may have left under root (see recoverState). Only the
+// process that runs the agent may do this: a temp file may belong to a
+// write in progress, so one-shot commands use NewAgentWith instead.
+func Boot(logger *telemetry.Logger, root string, cfg config.Config) *Agent {
+	rec := recoverState(root, config.Path(root, cfg.Paths.Weights), config.Path(root, cfg.Paths.Policy))
+	a := NewAgentWith(logger, root, cfg)
+	a.recovered = rec
+	return a
+}
+
+// recoverState repairs on-disk state before the agent loads it: stale temp
+// files from interrupted atomic writes are cleaned up, and a weights or
+// policy file that can't be decoded is quarantined and replaced from the
+// newest snapshot that carries a valid copy. Individual invalid rules
+// don't count: the policy loads without them.
+func recoverState(root, weightsPath, policyPath string) recovery {
+	actions, err := storage.RecoverTemps(filepath.Join(root, "data"))
+	if err != nil {
+		actions = append(actions, "temp file scan failed: "+err.Error())
+	}
+
+	weightsBad := storage.Exists(weightsPath) && storage.NewWeights().Load(weightsPath) != nil
+	_, _, perr := policy.LoadRules(policyPath)
+	policyBad := storage.Exists(policyPath) && perr != nil
+
+	if weightsBad || policyBad {
+		actions = append(actions, restoreFromSnapshot(root, weightsPath, policyPath, weightsBad, policyBad)...)
+	}
+
+	return recovery{actions: actions, weightsFailed: weightsBad, policyFailed: policyBad}
+}
+
+// logRecovery reports what recoverState did, if anything.
+func (a *Agent) logRecovery() {
+	if len(a.recovered.actions) == 0 {
+		return
+	}
+	for _, action := range a.recovered.actions {
+		fmt.Fprintln(a.out, "⚠ recovery:", action)
+	}
+	a.logger.Log(structs.NewEvent("RECOVERY", "system", map[string]any{
+		"actions":        a.recovered.actions,
+		"weights_failed": a.recovered.weightsFailed,
+		"policy_failed":  a.recovered.policyFailed,
+	}))
+}
+
+// restoreFromSnapshot quarantines the damaged files and rewrites them from
+// the newest snapshot holding a usable copy of each.
+func restoreFromSnapshot(root, weightsPath, policyPath string, weightsBad, policyBad bool) []string {
+	var actions []string
+	for _, bad := range []struct {
+		failed bool
+		path   string
+	}{{weightsBad, weightsPath}, {policyBad, policyPath}} {
+		if !bad.failed {
+			continue
+		}
+		dst, err := storage.Quarantine(bad.path)
+		if err != nil {
+			actions = append(actions, fmt.Sprintf("could not quarantine %s: %v", bad.path, err))
+			continue
+		}
+		actions = append(actions, fmt.Sprintf("quarantined corrupt %s as %s", bad.path, dst))
+	}
+
+	infos, _ := storage.ListSnapshots(root)
+	for i := len(infos) - 1; i >= 0 && (weightsBad || policyBad); i-- {
+		if infos[i].Err != nil {
+			continue
+		}
+		snap, err := storage.LoadSnapshot(infos[i].Path)
+		if err != nil {
+			continue
+		}
+		if weightsBad && snap.Counts != nil {
+			if err := storage.AtomicWriteJSON(weightsPath, snap.Counts); err == nil {
+				actions = append(actions, fmt.Sprintf("restored %s from %s", weightsPath, infos[i].Path))
+				weightsBad = false
+			}
+		}
+		if policyBad && len(snap.Rules) > 0 {
+			if rules, _, err := policy.DecodeRules(snap.Rules); err == nil {
+				if err := storage.AtomicWriteJSON(policyPath, rules); err == nil {
+					actions = append(actions, fmt.Sprintf("restored %s from %s", policyPath, infos[i].Path))
+					policyBad = false
+				}
+			}
+		}
+	}
+
+	if weightsBad {
+		actions = append(actions, "no valid snapshot for "+weightsPath+"; starting with empty beliefs")
+	}
+	if policyBad {
+		actions = append(actions, "no valid snapshot for "+policyPath+"; starting with no rules")
+	}
+	return actions
+}
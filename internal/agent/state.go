@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"neon/internal/clock"
+	"neon/internal/persona"
+	"neon/internal/policy"
+	"neon/internal/storage"
+	"neon/pkg/structs"
+)
+
+// Clock exposes the clock the agent runs on.
+func (a *Agent) Clock() clock.Clock { return a.clock }
+
+// Policy exposes the agent's rule engine.
+func (a *Agent) Policy() *policy.Engine { return a.policy }
+
+// Weights exposes the agent's word weights (beliefs).
+func (a *Agent) Weights() *storage.Weights { return a.weights }
+
+// Mood exposes the agent's mood engine.
+func (a *Agent) Mood() *persona.Engine { return a.mood }
+
+// Save persists beliefs, seen words, policy and mood state.
+func (a *Agent) Save() error {
+	return errors.Join(
+		a.weights.Save(a.path),
+		a.cognition.Save(a.seenPath),
+		a.policy.Save(),
+		a.mood.Save(a.moodPath),
+	)
+}
+
+// saveFailed reports err, if any, from saving part of the agent's state:
+// it is shown and logged as an ERROR event, so a write that keeps failing
+// doesn't go unnoticed.
+func (a *Agent) saveFailed(what string, err error) {
+	if err == nil {
+		return
+	}
+	fmt.Fprintf(a.out, "⚠ failed to save %s: %v\n", what, err)
+	a.logger.Log(structs.NewEvent("ERROR", "agent", map[string]any{
+		"action": "save",
+		"what":   what,
+		"error":  err.Error(),
+	}))
+}
+
+// loadFailed reports what couldn't be loaded from where: err if nothing
+// could, and otherwise each invalid rule that was skipped. Like a failed
+// save it is shown and logged as an ERROR event.
+func (a *Agent) loadFailed(where string, err error, skipped []error) {
+	if err != nil {
+		skipped = []error{err}
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(a.out, "⚠ failed to load %s: %v\n", where, err)
+		a.logger.Log(structs.NewEvent("ERROR", "agent", map[string]any{
+			"action": "load",
+			"what":   where,
+			"error":  err.Error(),
+		}))
+	}
+}
+
+// Snapshot captures the agent's live state under root/data/snapshots.
+func (a *Agent) Snapshot(notes string) (string, error) {
+	rules, err := json.Marshal(a.policy.Rules())
+	if err != nil {
+		return "", err
+	}
+	mood, err := json.Marshal(a.mood.State())
+	if err != nil {
+		return "", err
+	}
+
+	return storage.WriteSnapshot(a.root, storage.Snapshot{
+		Timestamp: a.clock.Now().UTC(),
+		Self:      map[string]any{"id": "NEON"},
+		Beliefs:   topWords(a.weights.TopN(a.cfg.Agent.BeliefTop)),
+		Counts:    a.weights.Snapshot(),
+		Features:  map[string]bool{},
+		Rules:     rules,
+		Mood:      mood,
+		Seen:      a.cognition.SeenWords(),
+		Notes:     notes,
+	})
+}
+
+// Restore replaces the agent's state with the snapshot's and persists it.
+// Everything is decoded before anything is changed, so a damaged snapshot
+// leaves the agent as it was. Parts the snapshot doesn't carry are left
+// untouched.
+func (a *Agent) Restore(s *storage.Snapshot) error {
+	var rules []policy.Rule
+	if len(s.Rules) > 0 {
+		var err error
+		var skipped []error
+		if rules, skipped, err = policy.DecodeRules(s.Rules); err != nil {
+			return fmt.Errorf("snapshot rules: %w", err)
+		}
+		a.loadFailed("snapshot rules", nil, skipped)
+	}
+	var st persona.State
+	if len(s.Mood) > 0 {
+		if err := json.Unmarshal(s.Mood, &st); err != nil {
+			return fmt.Errorf("snapshot mood: %w", err)
+		}
+	}
+
+	if len(s.Rules) > 0 {
+		a.policy.SetRules(rules)
+	}
+	if len(s.Mood) > 0 {
+		a.mood.Restore(st)
+	}
+	if s.Counts != nil {
+		a.weights.Replace(s.Counts)
+	}
+	if s.Seen != nil {
+		a.cognition.SetSeen(s.Seen)
+	}
+	return a.Save()
+}
+
+// topWords returns just the words of a TopN result.
+func topWords(top []storage.WordCount) []string {
+	words := make([]string, 0, len(top))
+	for _, wc := range top {
+		words = append(words, wc.Word)
+	}
+	return words
+}
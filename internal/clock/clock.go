@@ -0,0 +1,58 @@
+// Package clock lets time be injected, so a run can be replayed exactly.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time. Tick is called once per agent turn: the wall
+// clock ignores it, a simulated clock steps forward.
+type Clock interface {
+	Now() time.Time
+	Tick()
+}
+
+// System is the wall clock.
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+func (systemClock) Tick()          {}
+
+// Epoch is where simulated clocks start: a fixed, arbitrary instant.
+var Epoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Sim is a simulated clock. Its time only moves when Advance or Tick is
+// called, so everything that reads it sees the same times on every run.
+type Sim struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewSim returns a simulated clock set to start.
+func NewSim(start time.Time) *Sim {
+	return &Sim{now: start}
+}
+
+func (s *Sim) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// Advance moves the clock forward by d.
+func (s *Sim) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+// TickStep is how far Tick moves a simulated clock: one turn is a second.
+const TickStep = time.Second
+
+// Tick advances the clock by TickStep.
+func (s *Sim) Tick() {
+	s.Advance(TickStep)
+}
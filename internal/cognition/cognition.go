@@ -3,95 +3,177 @@ package cognition
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
-	"time"
 
+	"neon/internal/memory"
 	"neon/internal/persona"
 	"neon/internal/storage"
 )
 
+// DefaultEmotionThreshold is the affect level at which an emotion colours
+// responses.
+const DefaultEmotionThreshold = 0.6
+
+// Options tune a cognition engine. Zero values take the defaults.
+type Options struct {
+	EmotionThreshold float64 // affect level at which an emotion colours responses
+}
+
 type Engine struct {
+	opts    Options
 	weights *storage.Weights
 	rng     *rand.Rand
 	seen    map[string]bool // track seen words for novelty
+	dirty   bool            // seen changed since the last Save
+	memory  *memory.Store   // optional episodic memory
 }
 
-func NewEngine(weights *storage.Weights) *Engine {
+// NewEngine creates a cognition engine tuned by opts, drawing its
+// randomness from rng.
+func NewEngine(weights *storage.Weights, opts Options, rng *rand.Rand) *Engine {
+	if opts.EmotionThreshold <= 0 {
+		opts.EmotionThreshold = DefaultEmotionThreshold
+	}
 	return &Engine{
+		opts:    opts,
 		weights: weights,
-		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:     rng,
 		seen:    make(map[string]bool),
 	}
 }
 
-func (e *Engine) Respond(userText string, mood persona.Mood) string {
+// SetMemory lets Respond reference past conversations from mem.
+func (e *Engine) SetMemory(mem *memory.Store) {
+	e.memory = mem
+}
+
+// Respond answers userText in the voice of the current mood, or of a
+// strong emotion in affect if there is one.
+func (e *Engine) Respond(userText string, mood persona.Mood, affect persona.Affect) string {
+	base := e.moodBase(mood, affect)
+
+	// Prefer referencing a past conversation that shares words with this one.
+	if ref := e.recallReference(userText); ref != nil {
+		word := e.rarest(ref.Shared)
+		return fmt.Sprintf("(%s) %s %s, you said \"%s\" before.", mood, base, word, ref.Episode.Input)
+	}
+
 	top := e.weights.TopN(5)
 	words := make([]string, 0, len(top))
 	for _, wc := range top {
 		words = append(words, wc.Word)
 	}
 
-	var base string
+	if len(words) > 0 {
+		choice := words[e.rng.Intn(len(words))]
+		return fmt.Sprintf("(%s) %s %s", mood, base, choice)
+	}
+	return fmt.Sprintf("(%s) You said: %s", mood, userText)
+}
+
+// moodBase is how a response starts. An emotion at or above
+// EmotionThreshold outweighs the mood, frustration first.
+func (e *Engine) moodBase(mood persona.Mood, affect persona.Affect) string {
+	switch {
+	case affect[persona.Frustration] >= e.opts.EmotionThreshold:
+		return "I'm fed up with"
+	case affect[persona.Curiosity] >= e.opts.EmotionThreshold:
+		return "I wonder about"
+	}
 	switch mood {
 	case persona.MoodPositive:
-		base = "I like"
+		return "I like"
 	case persona.MoodNegative:
-		base = "I don't like"
+		return "I don't like"
 	default:
-		base = "I know"
+		return "I know"
 	}
+}
 
-	if len(words) > 0 {
-		choice := words[e.rng.Intn(len(words))]
-		return fmt.Sprintf("(%s) %s %s", mood, base, choice)
+// recallReference finds the most relevant past episode for userText,
+// ignoring earlier turns that were word-for-word the same.
+func (e *Engine) recallReference(userText string) *memory.Recall {
+	if e.memory == nil {
+		return nil
 	}
-	return fmt.Sprintf("(%s) You said: %s", mood, userText)
+	for _, r := range e.memory.ByKeywords(userText, 5) {
+		if !strings.EqualFold(strings.TrimSpace(r.Episode.Input), strings.TrimSpace(userText)) {
+			return &r
+		}
+	}
+	return nil
 }
 
-// ReflectIfNeeded may return a reflection, or "" if no reflection occurs.
-func (e *Engine) ReflectIfNeeded(userText string, mood persona.Mood, score float64) string {
-	words := storage.Tokenize(userText)
-	novelty := false
-	for _, w := range words {
-		if !e.seen[w] {
-			e.seen[w] = true
-			novelty = true
+// rarest picks the word with the lowest belief count, i.e. the most
+// specific thing two conversations have in common.
+func (e *Engine) rarest(words []string) string {
+	counts := e.weights.Snapshot()
+	best := words[0]
+	for _, w := range words[1:] {
+		if counts[w] < counts[best] {
+			best = w
 		}
 	}
+	return best
+}
 
-	// Conditions for reflection
-	trigger := false
+// Seen reports whether word has appeared in any input so far.
+func (e *Engine) Seen(word string) bool {
+	return e.seen[word]
+}
 
-	// 1. New word discovered
-	if novelty {
-		trigger = true
+// SeenWords returns every word seen so far, sorted.
+func (e *Engine) SeenWords() []string {
+	out := make([]string, 0, len(e.seen))
+	for w := range e.seen {
+		out = append(out, w)
 	}
+	sort.Strings(out)
+	return out
+}
 
-	// 2. Mood extremes
-	if score >= 2.0 || score <= -2.0 {
-		if e.rng.Float64() < 0.5 { // 50% chance
-			trigger = true
-		}
+// SetSeen replaces the set of seen words.
+func (e *Engine) SetSeen(words []string) {
+	e.seen = make(map[string]bool, len(words))
+	for _, w := range words {
+		e.seen[w] = true
 	}
+	e.dirty = true
+}
 
-	// 3. Small random chance always
-	if e.rng.Intn(6) == 0 { // ~1 in 6
-		trigger = true
+// Save persists the seen words if they changed since the last save.
+func (e *Engine) Save(path string) error {
+	if !e.dirty {
+		return nil
 	}
-
-	if !trigger {
-		return ""
+	if err := storage.AtomicWriteJSON(path, e.SeenWords()); err != nil {
+		return err
 	}
+	e.dirty = false
+	return nil
+}
 
-	// Build reflection from top memory
-	top := e.weights.TopN(3)
-	if len(top) == 0 {
-		return fmt.Sprintf("(%s) I don't know much yet.", mood)
+// Load replaces the seen words with those saved at path, if present.
+func (e *Engine) Load(path string) error {
+	if !storage.Exists(path) {
+		return nil
+	}
+	var words []string
+	if err := storage.ReadJSON(path, &words); err != nil {
+		return err
 	}
+	e.SetSeen(words)
+	e.dirty = false
+	return nil
+}
 
-	wordsOut := make([]string, 0, len(top))
-	for _, wc := range top {
-		wordsOut = append(wordsOut, wc.Word)
+// Notice marks every word of userText as seen.
+func (e *Engine) Notice(userText string) {
+	for _, w := range storage.Tokenize(userText) {
+		if !e.seen[w] {
+			e.seen[w] = true
+			e.dirty = true
+		}
 	}
-	return fmt.Sprintf("(%s) I keep thinking about: %s", mood, strings.Join(wordsOut, ", "))
 }
@@ -0,0 +1,278 @@
+// Package config holds every tunable of the agent in one typed struct.
+//
+// A Config starts from Default, is overlaid with a JSON file (Load), then
+// with NEON_* environment variables (ApplyEnv) and finally with -set
+// flags (Set), and is checked with Validate before anything uses it.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"neon/internal/clock"
+)
+
+// Config is the full set of tunables.
+type Config struct {
+	Paths      Paths      `json:"paths"`
+	Mood       Mood       `json:"mood"`
+	Cognition  Cognition  `json:"cognition"`
+	Agent      Agent      `json:"agent"`
+	Policy     Policy     `json:"policy"`
+	Snapshots  Snapshots  `json:"snapshots"`
+	Reflection Reflection `json:"reflection"`
+	Telemetry  Telemetry  `json:"telemetry"`
+}
+
+// Paths locate the agent's state files. Relative paths are resolved
+// against the agent's root directory.
+type Paths struct {
+	Weights  string `json:"weights"`
+	Policy   string `json:"policy"`
+	Mood     string `json:"mood"`
+	Episodes string `json:"episodes"`
+	World    string `json:"world"`
+	Seen     string `json:"seen"`
+	Lexicon  string `json:"lexicon"` // sentiment lexicon (empty = built-in)
+}
+
+// Mood tunes the persona mood engine. Each affect dimension decays toward
+// its baseline at its own rate, in units per second; Decay is valence's.
+type Mood struct {
+	Decay     float64 `json:"decay"`     // score units per second pulled toward zero
+	Threshold float64 `json:"threshold"` // initial |score| at which mood leaves neutral
+	Clamp     float64 `json:"clamp"`     // |score| never exceeds this
+
+	ArousalDecay     float64 `json:"arousal_decay"`
+	DominanceDecay   float64 `json:"dominance_decay"`
+	CuriosityDecay   float64 `json:"curiosity_decay"`
+	FrustrationDecay float64 `json:"frustration_decay"`
+	CalmDecay        float64 `json:"calm_decay"`
+}
+
+// Cognition tunes how the cognition engine responds.
+type Cognition struct {
+	EmotionThreshold float64 `json:"emotion_threshold"` // affect level at which an emotion colours responses
+}
+
+// Agent tunes the agent loop.
+//
+// A non-zero Seed makes a run deterministic: randomness is seeded with it
+// and the agent runs on a simulated clock that starts at a fixed instant
+// and advances one second per turn, so the same input produces the same
+// output and event log. Wall-clock timers (periodic health events and
+// interval reflections) are switched off in that mode.
+type Agent struct {
+	Seed int64 `json:"seed"`
+
+	RuleTop           int     `json:"rule_top"`            // top words that get rules each turn
+	InputTop          int     `json:"input_top"`           // top words logged and given to templates
+	BeliefTop         int     `json:"belief_top"`          // top words recorded as snapshot beliefs
+	WorldLearningRate float64 `json:"world_learning_rate"` // world model SGD step
+}
+
+// Policy tunes rule guardrails, garbage collection and curiosity.
+type Policy struct {
+	MaxRules           int      `json:"max_rules"`
+	MaxEditsPerMinute  int      `json:"max_edits_per_minute"`
+	Deny               []string `json:"deny"`
+	GCEvery            int      `json:"gc_every"` // turns between GC passes (0 disables)
+	GCMinAge           Duration `json:"gc_min_age"`
+	GCIdle             Duration `json:"gc_idle"`
+	GCTop              int      `json:"gc_top"` // size of the top set that keeps word rules alive
+	CuriosityThreshold float64  `json:"curiosity_threshold"`
+}
+
+// Snapshots tunes automatic snapshots.
+type Snapshots struct {
+	EveryTurns     int      `json:"every_turns"`
+	Every          Duration `json:"every"`
+	BeforeMutation bool     `json:"before_mutation"`
+	OnExit         bool     `json:"on_exit"`
+}
+
+// Reflection tunes the scheduled reflection job.
+type Reflection struct {
+	EveryTurns int      `json:"every_turns"`
+	Every      Duration `json:"every"`
+}
+
+// Telemetry tunes the event logger.
+type Telemetry struct {
+	Buffer         int      `json:"buffer"`          // events queued before new ones are dropped
+	HealthInterval Duration `json:"health_interval"` // between HEALTH events (0 disables)
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	return Config{
+		Paths: Paths{
+			Weights:  filepath.Join("data", "beliefs", "weights.json"),
+			Policy:   filepath.Join("data", "policy", "policy.json"),
+			Mood:     filepath.Join("data", "persona", "mood.json"),
+			Episodes: filepath.Join("data", "memory", "episodes.jsonl"),
+			World:    filepath.Join("data", "world", "model.json"),
+			Seen:     filepath.Join("data", "beliefs", "seen.json"),
+		},
+		Mood: Mood{
+			Decay:     0.05,
+			Threshold: 0.75,
+			Clamp:     5,
+
+			ArousalDecay:     0.02,
+			DominanceDecay:   0.01,
+			CuriosityDecay:   0.02,
+			FrustrationDecay: 0.01,
+			CalmDecay:        0.01,
+		},
+		Cognition: Cognition{
+			EmotionThreshold: 0.6,
+		},
+		Agent: Agent{
+			RuleTop:           3,
+			InputTop:          5,
+			BeliefTop:         10,
+			WorldLearningRate: 0.05,
+		},
+		Policy: Policy{
+			MaxRules:          50,
+			MaxEditsPerMinute: 2,
+			Deny: []string{
+				"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from",
+				"i", "in", "is", "it", "me", "my", "of", "on", "or", "so", "that",
+				"the", "to", "with", "you",
+			},
+			GCEvery:            10,
+			GCMinAge:           Duration(time.Hour),
+			GCIdle:             Duration(time.Hour),
+			GCTop:              10,
+			CuriosityThreshold: 0.5,
+		},
+		Snapshots: Snapshots{
+			EveryTurns:     25,
+			Every:          Duration(15 * time.Minute),
+			BeforeMutation: true,
+			OnExit:         true,
+		},
+		Reflection: Reflection{
+			EveryTurns: 10,
+			Every:      Duration(10 * time.Minute),
+		},
+		Telemetry: Telemetry{
+			Buffer:         100,
+			HealthInterval: Duration(30 * time.Second),
+		},
+	}
+}
+
+// Load overlays the JSON file at path on the defaults. Fields the file
+// leaves out keep their default; unknown fields are an error, to catch
+// typos.
+func Load(path string) (Config, error) {
+	c := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Path resolves p against root unless it is absolute.
+func Path(root, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(root, p)
+}
+
+// Deterministic reports whether the run is seeded (see Agent).
+func (c *Config) Deterministic() bool {
+	return c.Agent.Seed != 0
+}
+
+// Clock returns the clock a run under c uses: a simulated clock starting
+// at clock.Epoch if it is deterministic, the system clock otherwise.
+func (c *Config) Clock() clock.Clock {
+	if c.Deterministic() {
+		return clock.NewSim(clock.Epoch)
+	}
+	return clock.System
+}
+
+// StopTimers switches off the settings driven by the wall clock, which a
+// deterministic run can't reproduce.
+func (c *Config) StopTimers() {
+	c.Reflection.Every = 0
+	c.Snapshots.Every = 0
+	c.Telemetry.HealthInterval = 0
+}
+
+// Validate reports every setting that is out of range.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	for _, p := range [][2]string{
+		{"paths.weights", c.Paths.Weights}, {"paths.policy", c.Paths.Policy}, {"paths.mood", c.Paths.Mood},
+		{"paths.episodes", c.Paths.Episodes}, {"paths.world", c.Paths.World}, {"paths.seen", c.Paths.Seen},
+	} {
+		check(p[1] != "", "%s must be set", p[0])
+	}
+
+	check(c.Mood.Decay > 0, "mood.decay must be positive, got %g", c.Mood.Decay)
+	check(c.Mood.Clamp > 0, "mood.clamp must be positive, got %g", c.Mood.Clamp)
+	check(c.Mood.Threshold > 0 && c.Mood.Threshold < c.Mood.Clamp,
+		"mood.threshold must be in (0, mood.clamp), got %g", c.Mood.Threshold)
+	for _, d := range []struct {
+		key  string
+		rate float64
+	}{
+		{"mood.arousal_decay", c.Mood.ArousalDecay}, {"mood.dominance_decay", c.Mood.DominanceDecay},
+		{"mood.curiosity_decay", c.Mood.CuriosityDecay}, {"mood.frustration_decay", c.Mood.FrustrationDecay},
+		{"mood.calm_decay", c.Mood.CalmDecay},
+	} {
+		check(d.rate > 0, "%s must be positive, got %g", d.key, d.rate)
+	}
+
+	check(c.Cognition.EmotionThreshold > 0 && c.Cognition.EmotionThreshold <= 1,
+		"cognition.emotion_threshold must be in (0, 1], got %g", c.Cognition.EmotionThreshold)
+
+	check(c.Agent.RuleTop >= 0, "agent.rule_top must not be negative, got %d", c.Agent.RuleTop)
+	check(c.Agent.InputTop > 0, "agent.input_top must be positive, got %d", c.Agent.InputTop)
+	check(c.Agent.BeliefTop > 0, "agent.belief_top must be positive, got %d", c.Agent.BeliefTop)
+	check(c.Agent.WorldLearningRate > 0 && c.Agent.WorldLearningRate <= 1,
+		"agent.world_learning_rate must be in (0, 1], got %g", c.Agent.WorldLearningRate)
+
+	check(c.Policy.MaxRules >= 0, "policy.max_rules must not be negative, got %d", c.Policy.MaxRules)
+	check(c.Policy.MaxEditsPerMinute >= 0, "policy.max_edits_per_minute must not be negative, got %d", c.Policy.MaxEditsPerMinute)
+	check(c.Policy.GCEvery >= 0, "policy.gc_every must not be negative, got %d", c.Policy.GCEvery)
+	check(c.Policy.GCMinAge >= 0, "policy.gc_min_age must not be negative, got %s", c.Policy.GCMinAge)
+	check(c.Policy.GCIdle >= 0, "policy.gc_idle must not be negative, got %s", c.Policy.GCIdle)
+	check(c.Policy.GCTop > 0, "policy.gc_top must be positive, got %d", c.Policy.GCTop)
+	check(c.Policy.CuriosityThreshold >= 0 && c.Policy.CuriosityThreshold <= 1,
+		"policy.curiosity_threshold must be in [0, 1], got %g", c.Policy.CuriosityThreshold)
+
+	check(c.Snapshots.EveryTurns >= 0, "snapshots.every_turns must not be negative, got %d", c.Snapshots.EveryTurns)
+	check(c.Snapshots.Every >= 0, "snapshots.every must not be negative, got %s", c.Snapshots.Every)
+	check(c.Reflection.EveryTurns >= 0, "reflection.every_turns must not be negative, got %d", c.Reflection.EveryTurns)
+	check(c.Reflection.Every >= 0, "reflection.every must not be negative, got %s", c.Reflection.Every)
+
+	check(c.Telemetry.Buffer > 0, "telemetry.buffer must be positive, got %d", c.Telemetry.Buffer)
+	check(c.Telemetry.HealthInterval >= 0, "telemetry.health_interval must not be negative, got %s", c.Telemetry.HealthInterval)
+
+	return errors.Join(errs...)
+}
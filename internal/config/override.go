@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written as a string like "15m" in JSON.
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"15m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// Keys lists every settable key, e.g. "mood.decay", in sorted order.
+func (c *Config) Keys() []string {
+	var keys []string
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		section := jsonName(v.Type().Field(i))
+		st := v.Field(i).Type()
+		for j := 0; j < st.NumField(); j++ {
+			keys = append(keys, section+"."+jsonName(st.Field(j)))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Set assigns value to the setting named by key ("section.field", using
+// the JSON names). Lists are comma separated; durations use Go syntax.
+func (c *Config) Set(key, value string) error {
+	section, name, ok := strings.Cut(key, ".")
+	if !ok {
+		return fmt.Errorf("config key %q must look like section.field", key)
+	}
+	sv, ok := field(reflect.ValueOf(c).Elem(), section)
+	if !ok {
+		return fmt.Errorf("unknown config section %q", section)
+	}
+	fv, ok := field(sv, name)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	bad := func(err error) error { return fmt.Errorf("config %s=%q: %w", key, value, err) }
+	switch {
+	case fv.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return bad(err)
+		}
+		fv.SetInt(int64(d))
+	case fv.Kind() == reflect.String:
+		fv.SetString(value)
+	case fv.Kind() == reflect.Int || fv.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return bad(err)
+		}
+		fv.SetInt(n)
+	case fv.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return bad(err)
+		}
+		fv.SetFloat(f)
+	case fv.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return bad(err)
+		}
+		fv.SetBool(b)
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+		var list []string
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		fv.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("config key %q has unsupported type %s", key, fv.Type())
+	}
+	return nil
+}
+
+// EnvName is the environment variable that overrides key, e.g.
+// NEON_MOOD_DECAY for "mood.decay".
+func EnvName(key string) string {
+	return "NEON_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// ApplyEnv applies every NEON_* variable that lookup (usually
+// os.LookupEnv) finds for a known key.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	for _, key := range c.Keys() {
+		if v, ok := lookup(EnvName(key)); ok {
+			if err := c.Set(key, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// field finds the struct field of v with the given JSON name.
+func field(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// FileName is the config file looked for in an agent's root when no
+// other is given.
+const FileName = "neon.json"
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ProfilesDir holds one isolated agent root per profile under root.
+func ProfilesDir(root string) string {
+	return filepath.Join(root, "profiles")
+}
+
+// ProfileRoot returns the root directory of the named profile under root.
+// The empty profile is root itself.
+func ProfileRoot(root, name string) (string, error) {
+	if name == "" {
+		return root, nil
+	}
+	if !profileName.MatchString(name) {
+		return "", fmt.Errorf("bad profile name %q: use letters, digits, '-' and '_'", name)
+	}
+	return filepath.Join(ProfilesDir(root), name), nil
+}
+
+// Profiles lists the profiles that exist under root, sorted by name.
+func Profiles(root string) ([]string, error) {
+	entries, err := os.ReadDir(ProfilesDir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && profileName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -1,3 +1,178 @@
 package memory
 
-// Episodic memory store.
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"neon/internal/clock"
+	"neon/internal/persona"
+	"neon/internal/storage"
+)
+
+// Episode is one remembered interaction turn.
+type Episode struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Input     string       `json:"input"`
+	Mood      persona.Mood `json:"mood"`
+	Score     float64      `json:"score"`
+	Response  string       `json:"response"`
+	Rules     []string     `json:"rules,omitempty"` // trigger words of rules that fired
+}
+
+// Recall is an episode returned from a keyword search together with the
+// tokens it shares with the query.
+type Recall struct {
+	Episode Episode  `json:"episode"`
+	Shared  []string `json:"shared"`
+}
+
+// Store is an episodic memory backed by an append-only JSONL segment.
+// It's concurrency-safe; all episodes are kept in memory for recall.
+type Store struct {
+	mu       sync.RWMutex
+	path     string
+	clock    clock.Clock // stamps episodes recorded without a time
+	episodes []Episode
+}
+
+// NewStore loads episodes from path (if it exists) and appends new ones
+// there, stamping them with clk unless they carry a time.
+func NewStore(path string, clk clock.Clock) (*Store, error) {
+	s := &Store{path: path, clock: clk}
+	if err := s.load(); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var ep Episode
+		// A torn final line from a crash is skipped rather than fatal.
+		if err := json.Unmarshal(sc.Bytes(), &ep); err != nil {
+			continue
+		}
+		s.episodes = append(s.episodes, ep)
+	}
+	return sc.Err()
+}
+
+// Record appends an episode to the store and to disk.
+func (s *Store) Record(ep Episode) error {
+	if ep.Timestamp.IsZero() {
+		ep.Timestamp = s.clock.Now().UTC()
+	}
+	line, err := json.Marshal(ep)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.episodes = append(s.episodes, ep)
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Len returns the number of stored episodes.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.episodes)
+}
+
+// Recent returns up to n episodes (all if n <= 0), newest first.
+func (s *Store) Recent(n int) []Episode {
+	return s.filter(n, func(Episode) bool { return true })
+}
+
+// ByMood returns up to n of the newest episodes recorded in the given mood.
+func (s *Store) ByMood(mood persona.Mood, n int) []Episode {
+	return s.filter(n, func(ep Episode) bool { return ep.Mood == mood })
+}
+
+// filter walks episodes newest first, keeping up to n (all if n <= 0)
+// for which keep returns true.
+func (s *Store) filter(n int, keep func(Episode) bool) []Episode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Episode
+	for i := len(s.episodes) - 1; i >= 0 && (n <= 0 || len(out) < n); i-- {
+		if keep(s.episodes[i]) {
+			out = append(out, s.episodes[i])
+		}
+	}
+	return out
+}
+
+// ByKeywords returns up to n episodes whose input shares tokens with text,
+// ordered by the number of shared tokens and then by recency.
+func (s *Store) ByKeywords(text string, n int) []Recall {
+	query := make(map[string]bool)
+	for _, tok := range storage.Tokenize(text) {
+		query[tok] = true
+	}
+	if len(query) == 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hits []Recall
+	for i := len(s.episodes) - 1; i >= 0; i-- {
+		ep := s.episodes[i]
+		seen := make(map[string]bool)
+		var shared []string
+		for _, tok := range storage.Tokenize(ep.Input) {
+			if query[tok] && !seen[tok] {
+				seen[tok] = true
+				shared = append(shared, tok)
+			}
+		}
+		if len(shared) > 0 {
+			hits = append(hits, Recall{Episode: ep, Shared: shared})
+		}
+	}
+
+	// hits are already newest first; a stable sort keeps that as tiebreak.
+	sort.SliceStable(hits, func(i, j int) bool {
+		return len(hits[i].Shared) > len(hits[j].Shared)
+	})
+	if n > 0 && n < len(hits) {
+		hits = hits[:n]
+	}
+	return hits
+}
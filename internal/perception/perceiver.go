@@ -0,0 +1,30 @@
+package perception
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Percept is a single unit of input observed by the agent.
+type Percept struct {
+	Source    string    `json:"source"`
+	Timestamp time.Time `json:"timestamp"`
+	Text      string    `json:"text"`
+}
+
+// Perceiver is anything NEON can perceive input from.
+// Next blocks until a percept is available. It returns io.EOF once the
+// source is exhausted, or ctx.Err() if ctx is cancelled first.
+type Perceiver interface {
+	Next(ctx context.Context) (Percept, error)
+}
+
+// newPercept builds a percept stamped with the current time.
+func newPercept(source, text string) Percept {
+	return Percept{
+		Source:    source,
+		Timestamp: time.Now(),
+		Text:      strings.TrimSpace(text),
+	}
+}
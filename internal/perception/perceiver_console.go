@@ -1,3 +1,83 @@
 package perception
 
-// Console perceiver.
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Console reads one percept per line from an interactive stream,
+// writing a prompt before each read.
+//
+// Reads happen on a background goroutine so Next can return as soon as
+// its context is cancelled, even while blocked waiting for a line.
+type Console struct {
+	reader *bufio.Reader
+	prompt io.Writer
+
+	start sync.Once
+	want  chan struct{} // asks the reader goroutine for one more line
+	lines chan consoleRead
+}
+
+type consoleRead struct {
+	line string
+	err  error
+}
+
+// NewConsole creates a console perceiver reading from in. If prompt is
+// non-nil, ">> " is written to it before each line is read.
+func NewConsole(in io.Reader, prompt io.Writer) *Console {
+	return &Console{
+		reader: bufio.NewReader(in),
+		prompt: prompt,
+		want:   make(chan struct{}, 1),
+		lines:  make(chan consoleRead, 1),
+	}
+}
+
+func (c *Console) Next(ctx context.Context) (Percept, error) {
+	if err := ctx.Err(); err != nil {
+		return Percept{}, err
+	}
+	c.start.Do(func() { go c.read() })
+
+	// A line requested by an interrupted Next may already be waiting.
+	var r consoleRead
+	select {
+	case r = <-c.lines:
+	default:
+		if c.prompt != nil {
+			fmt.Fprint(c.prompt, ">> ")
+		}
+		select {
+		case c.want <- struct{}{}:
+		default: // a request is already outstanding
+		}
+		select {
+		case r = <-c.lines:
+		case <-ctx.Done():
+			return Percept{}, ctx.Err()
+		}
+	}
+
+	if r.err != nil && (r.err != io.EOF || r.line == "") {
+		return Percept{}, r.err
+	}
+	return newPercept("console", r.line), nil
+}
+
+// read serves line requests. Once the stream fails every later request
+// gets the same error.
+func (c *Console) read() {
+	var err error
+	for range c.want {
+		line := ""
+		if err == nil {
+			line, err = c.reader.ReadString('\n')
+		}
+		c.lines <- consoleRead{line: line, err: err}
+	}
+}
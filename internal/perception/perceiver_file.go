@@ -0,0 +1,75 @@
+package perception
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// File reads one percept per line from a file on disk. With follow set it
+// behaves like `tail -f`: at end of file it waits for more lines instead
+// of returning io.EOF.
+type File struct {
+	path    string
+	follow  bool
+	poll    time.Duration
+	f       *os.File
+	reader  *bufio.Reader
+	partial string
+}
+
+// NewFile opens path for reading.
+func NewFile(path string, follow bool) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		path:   path,
+		follow: follow,
+		poll:   250 * time.Millisecond,
+		f:      f,
+		reader: bufio.NewReader(f),
+	}, nil
+}
+
+func (p *File) Next(ctx context.Context) (Percept, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Percept{}, err
+		}
+		chunk, err := p.reader.ReadString('\n')
+		p.partial += chunk
+		if err == nil {
+			line := p.partial
+			p.partial = ""
+			return newPercept("file", line), nil
+		}
+		if err != io.EOF {
+			return Percept{}, err
+		}
+		if !p.follow {
+			// Last line without a trailing newline still counts.
+			if p.partial != "" {
+				line := p.partial
+				p.partial = ""
+				return newPercept("file", line), nil
+			}
+			return Percept{}, io.EOF
+		}
+
+		// Following: wait for the writer to append more.
+		select {
+		case <-ctx.Done():
+			return Percept{}, ctx.Err()
+		case <-time.After(p.poll):
+		}
+	}
+}
+
+// Close releases the underlying file.
+func (p *File) Close() error {
+	return p.f.Close()
+}
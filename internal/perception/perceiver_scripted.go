@@ -0,0 +1,34 @@
+package perception
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Scripted replays a fixed list of lines, then reports io.EOF.
+// It is meant for tests, batch jobs and replays.
+type Scripted struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+}
+
+// NewScripted creates a perceiver that yields lines in order.
+func NewScripted(lines ...string) *Scripted {
+	return &Scripted{lines: lines}
+}
+
+func (s *Scripted) Next(ctx context.Context) (Percept, error) {
+	if err := ctx.Err(); err != nil {
+		return Percept{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next >= len(s.lines) {
+		return Percept{}, io.EOF
+	}
+	line := s.lines[s.next]
+	s.next++
+	return newPercept("script", line), nil
+}
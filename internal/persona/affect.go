@@ -0,0 +1,128 @@
+package persona
+
+import (
+	"math"
+	"strings"
+)
+
+// Affect dimensions. Valence is the mood score the discrete Mood is
+// derived from and ranges over ±clamp; the others range over [0, 1].
+const (
+	Valence     = "valence"     // how pleasant things feel
+	Arousal     = "arousal"     // how stirred up the agent is
+	Dominance   = "dominance"   // how much in control it feels
+	Curiosity   = "curiosity"   // how much it wants to know more
+	Frustration = "frustration" // built up by repeated negativity
+	Calm        = "calm"        // settles as arousal fades
+)
+
+// Dimensions lists every affect dimension in a fixed order.
+var Dimensions = []string{Valence, Arousal, Dominance, Curiosity, Frustration, Calm}
+
+// IsDimension reports whether name is an affect dimension.
+func IsDimension(name string) bool {
+	for _, d := range Dimensions {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Affect is a reading of every affect dimension.
+type Affect map[string]float64
+
+// dimension describes how one dimension other than valence behaves.
+type dimension struct {
+	baseline float64 // value it decays toward
+	decay    float64 // units per second pulled toward baseline
+}
+
+// dimensions builds the non-valence dimensions, taking their decay rates
+// from decays where set.
+func dimensions(decays map[string]float64) map[string]dimension {
+	dims := map[string]dimension{
+		Arousal:     {baseline: 0, decay: 0.02},
+		Dominance:   {baseline: 0.5, decay: 0.01},
+		Curiosity:   {baseline: 0, decay: 0.02},
+		Frustration: {baseline: 0, decay: 0.01},
+		Calm:        {baseline: 0.5, decay: 0.01},
+	}
+	for name, r := range decays {
+		if d, ok := dims[name]; ok && r > 0 {
+			d.decay = r
+			dims[name] = d
+		}
+	}
+	return dims
+}
+
+// Affect returns the current reading of every dimension.
+func (e *Engine) Affect() Affect {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	a := make(Affect, len(Dimensions))
+	for k, v := range e.affect {
+		a[k] = v
+	}
+	a[Valence] = e.score
+	return a
+}
+
+// Nudge moves a dimension other than valence by delta, within [0, 1].
+// Unknown dimensions are ignored.
+func (e *Engine) Nudge(dim string, delta float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nudge(dim, delta)
+}
+
+// nudge is Nudge for callers holding e.mu.
+func (e *Engine) nudge(dim string, delta float64) {
+	if _, ok := e.dims[dim]; !ok {
+		return
+	}
+	e.affect[dim] = max(0, min(1, e.affect[dim]+delta))
+}
+
+// decayAffect pulls every dimension other than valence toward its
+// baseline for dt seconds. Caller must hold e.mu.
+func (e *Engine) decayAffect(dt float64) {
+	for name, d := range e.dims {
+		v, step := e.affect[name], d.decay*dt
+		if v > d.baseline {
+			e.affect[name] = max(d.baseline, v-step)
+		} else {
+			e.affect[name] = min(d.baseline, v+step)
+		}
+	}
+}
+
+// appraise updates the dimensions other than valence for text, whose
+// valence was delta. Caller must hold e.mu.
+func (e *Engine) appraise(text string, delta float64) {
+	// One text moves any dimension by a bounded amount.
+	delta = max(-1, min(1, delta))
+
+	// Strong feelings and exclamations stir the agent up and unsettle it.
+	stir := 0.3*math.Abs(delta) + 0.05*min(3, float64(strings.Count(text, "!")))
+	e.nudge(Arousal, stir)
+	e.nudge(Calm, -stir)
+	if stir == 0 {
+		e.nudge(Calm, 0.05)
+	}
+
+	// Negativity builds frustration, which good news only slowly eases;
+	// both shift the sense of being in control.
+	if delta < 0 {
+		e.nudge(Frustration, -0.25*delta)
+	} else {
+		e.nudge(Frustration, -0.1*delta)
+	}
+	e.nudge(Dominance, 0.1*delta)
+
+	// Questions are a sign of interest.
+	if strings.Contains(text, "?") {
+		e.nudge(Curiosity, 0.2)
+	}
+}
@@ -0,0 +1,145 @@
+package persona
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"neon/internal/storage"
+)
+
+// Lexicon scores the valence of text. Words carry a weight (positive or
+// negative); intensifiers scale the next sentiment word; negators flip the
+// sentiment words that follow them within the same clause.
+type Lexicon struct {
+	Words        map[string]float64 `json:"words"`        // word → valence
+	Intensifiers map[string]float64 `json:"intensifiers"` // word → multiplier
+	Negators     []string           `json:"negators"`
+	// NegationScope is how many following words a negator reaches.
+	NegationScope int `json:"negation_scope"`
+	// Negation scales a negated word's valence after flipping it, so
+	// "not good" is milder than "bad".
+	Negation float64 `json:"negation"`
+
+	negators map[string]bool
+}
+
+//go:embed lexicon.json
+var defaultLexicon []byte
+
+// DefaultLexicon returns the built-in lexicon.
+func DefaultLexicon() *Lexicon {
+	l, err := ParseLexicon(defaultLexicon)
+	if err != nil {
+		panic("persona: built-in lexicon: " + err.Error())
+	}
+	return l
+}
+
+// LoadLexicon reads a lexicon from a JSON file shaped like the built-in
+// one (see lexicon.json).
+func LoadLexicon(path string) (*Lexicon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := ParseLexicon(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+// ParseLexicon decodes and checks a JSON lexicon. Entries are lowercased
+// so they match the tokens storage.Tokenize produces.
+func ParseLexicon(data []byte) (*Lexicon, error) {
+	var l Lexicon
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	if len(l.Words) == 0 {
+		return nil, fmt.Errorf("lexicon has no words")
+	}
+	if l.NegationScope < 0 {
+		return nil, fmt.Errorf("negation_scope must not be negative, got %d", l.NegationScope)
+	}
+	if l.Negation < 0 || l.Negation > 1 {
+		return nil, fmt.Errorf("negation must be in [0, 1], got %g", l.Negation)
+	}
+
+	words := make(map[string]float64, len(l.Words))
+	for w, v := range l.Words {
+		words[strings.ToLower(w)] = v
+	}
+	l.Words = words
+	intensifiers := make(map[string]float64, len(l.Intensifiers))
+	for w, m := range l.Intensifiers {
+		if m <= 0 {
+			return nil, fmt.Errorf("intensifier %q must be positive, got %g", w, m)
+		}
+		intensifiers[strings.ToLower(w)] = m
+	}
+	l.Intensifiers = intensifiers
+	l.negators = make(map[string]bool, len(l.Negators))
+	for _, w := range l.Negators {
+		l.negators[strings.ToLower(w)] = true
+	}
+	return &l, nil
+}
+
+// Score returns the valence of text: the sum of its sentiment words'
+// weights, each scaled by the intensifiers right before it and flipped if
+// a negator precedes it within NegationScope words of the same clause.
+// Words match whole tokens only, so "bug" doesn't fire inside "debug".
+func (l *Lexicon) Score(text string) float64 {
+	score := 0.0
+	for _, clause := range clauses(text) {
+		negated := 0 // words still in the current negator's scope
+		boost := 1.0
+		for _, tok := range storage.Tokenize(clause) {
+			if l.negators[tok] {
+				negated = l.NegationScope
+				continue
+			}
+			if m, ok := l.Intensifiers[tok]; ok {
+				boost *= m
+				continue
+			}
+			if v, ok := l.Words[tok]; ok {
+				v *= boost
+				if negated > 0 {
+					v *= -l.Negation
+				}
+				score += v
+			}
+			boost = 1
+			if negated > 0 {
+				negated--
+			}
+		}
+	}
+
+	t := strings.TrimSpace(text)
+	// Amplifier for !!!
+	if strings.Contains(t, "!!!") {
+		if score > 0 {
+			score += 0.25
+		} else if score < 0 {
+			score -= 0.25
+		}
+	}
+	// Soften negative if phrased as a question
+	if strings.HasSuffix(t, "?") && score < 0 {
+		score *= 0.8
+	}
+	return score
+}
+
+// clauses splits text at punctuation that ends a negation's reach.
+func clauses(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune(".,;:!?", r)
+	})
+}
@@ -1,9 +1,11 @@
 package persona
 
 import (
-	"strings"
 	"sync"
 	"time"
+
+	"neon/internal/clock"
+	"neon/internal/storage"
 )
 
 // Mood represents NEON's coarse affective state.
@@ -15,28 +17,88 @@ const (
 	MoodNegative Mood = "negative"
 )
 
-// Engine tracks mood over time using a very simple keyword–valence model.
-// It’s concurrency-safe and has zero external deps.
+// Engine tracks affect over time: a valence score from a lexicon, mapped
+// to the coarse Mood, plus the other dimensions of Affect, each decaying
+// toward its baseline at its own rate.
 type Engine struct {
 	mu         sync.RWMutex
 	current    Mood
-	score      float64 // running sentiment score
+	score      float64 // running sentiment score (valence)
+	affect     Affect  // the other dimensions
+	dims       map[string]dimension
 	lastUpdate time.Time
 	// decay controls how quickly the score drifts back toward zero per second.
 	decay float64
+	// threshold is the |score| at which mood turns positive or negative:
+	// the configured one, unless tuned by SetThreshold.
+	threshold  float64
+	configured float64
+	tuned      bool
+	// clamp bounds |score| so it can't run away.
+	clamp   float64
+	lexicon *Lexicon
+	clock   clock.Clock
+}
+
+// State is the persisted part of the mood engine. Affect holds the
+// dimensions other than valence, which is Score; states saved before
+// affect existed have none.
+//
+// Threshold is only restored if Tuned is set, i.e. it was changed with
+// SetThreshold (a mood_threshold proposal). Otherwise the configured
+// threshold applies, so changing mood.threshold takes effect on the next
+// run.
+type State struct {
+	Score     float64   `json:"score"`
+	Threshold float64   `json:"threshold"`
+	Tuned     bool      `json:"tuned,omitempty"`
+	Affect    Affect    `json:"affect,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// NewEngine creates a new mood engine. decay is the score units per second
-// pulled toward zero (e.g., 0.05 means ~0.05/sec toward neutral).
-func NewEngine(decay float64) *Engine {
-	if decay <= 0 {
-		decay = 0.05
+// DefaultThreshold is the |score| at which mood leaves neutral.
+const DefaultThreshold = 0.75
+
+// Options tune a mood engine. Zero values take the defaults.
+type Options struct {
+	Decay     float64 // score units per second pulled toward zero
+	Threshold float64 // |score| at which mood leaves neutral
+	Clamp     float64 // |score| never exceeds this
+
+	// Decays holds the units per second each dimension other than
+	// valence is pulled toward its baseline, by dimension name.
+	Decays map[string]float64
+}
+
+// NewEngine creates a new mood engine tuned by opts, measuring time on
+// clk. A Decay of 0.05 means the score drifts ~0.05/sec toward neutral.
+func NewEngine(opts Options, clk clock.Clock) *Engine {
+	if opts.Decay <= 0 {
+		opts.Decay = 0.05
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Clamp <= 0 {
+		opts.Clamp = 5
+	}
+	dims := dimensions(opts.Decays)
+	affect := make(Affect, len(dims))
+	for name, d := range dims {
+		affect[name] = d.baseline
 	}
 	return &Engine{
 		current:    MoodNeutral,
 		score:      0,
-		lastUpdate: time.Now(),
-		decay:      decay,
+		affect:     affect,
+		dims:       dims,
+		lastUpdate: clk.Now(),
+		decay:      opts.Decay,
+		threshold:  opts.Threshold,
+		configured: opts.Threshold,
+		clamp:      opts.Clamp,
+		lexicon:    DefaultLexicon(),
+		clock:      clk,
 	}
 }
 
@@ -47,11 +109,19 @@ func (e *Engine) Get() (Mood, float64) {
 	return e.current, e.score
 }
 
+// SetLexicon replaces the lexicon input is scored with.
+func (e *Engine) SetLexicon(l *Lexicon) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lexicon = l
+}
+
 // UpdateFromText ingests a user text chunk, applies decay since last update,
-// then adjusts the score by keyword valence, and refreshes the discrete mood.
+// then adjusts the score by the text's valence and the other dimensions by
+// how it reads, and refreshes the discrete mood.
 // Returns the new Mood and score.
 func (e *Engine) UpdateFromText(text string) (Mood, float64) {
-	now := time.Now()
+	now := e.clock.Now()
 
 	e.mu.Lock()
 	// Apply time decay toward zero
@@ -62,73 +132,112 @@ func (e *Engine) UpdateFromText(text string) (Mood, float64) {
 		} else if e.score < 0 {
 			e.score = min(0, e.score+e.decay*dt)
 		}
+		e.decayAffect(dt)
 	}
 
-	// Adjust score based on simple keyword valence.
-	delta := sentimentDelta(text)
+	delta := e.lexicon.Score(text)
 	e.score += delta
+	e.appraise(text, delta)
 
 	// Clamp score to a reasonable range so it can’t run away.
-	if e.score > 5 {
-		e.score = 5
-	} else if e.score < -5 {
-		e.score = -5
+	if e.score > e.clamp {
+		e.score = e.clamp
+	} else if e.score < -e.clamp {
+		e.score = -e.clamp
 	}
 
-	// Map score to discrete mood
+	e.refresh()
+	e.lastUpdate = now
+	m, s := e.current, e.score
+	e.mu.Unlock()
+	return m, s
+}
+
+// refresh maps the score to a discrete mood. Caller must hold e.mu.
+func (e *Engine) refresh() {
 	switch {
-	case e.score >= 0.75:
+	case e.score >= e.threshold:
 		e.current = MoodPositive
-	case e.score <= -0.75:
+	case e.score <= -e.threshold:
 		e.current = MoodNegative
 	default:
 		e.current = MoodNeutral
 	}
+}
 
-	e.lastUpdate = now
-	m, s := e.current, e.score
-	e.mu.Unlock()
-	return m, s
+// Threshold returns the |score| at which mood leaves neutral.
+func (e *Engine) Threshold() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.threshold
 }
 
-// sentimentDelta computes a tiny valence delta from the given text.
-func sentimentDelta(text string) float64 {
-	t := strings.ToLower(text)
+// Clamp returns the largest |score| the engine allows.
+func (e *Engine) Clamp() float64 {
+	return e.clamp
+}
 
-	positives := [...]string{
-		"good", "great", "awesome", "love", "nice", "cool", "amazing", "yay",
-		"thanks", "thank you", "excellent", "perfect", "happy", "success",
-	}
-	negatives := [...]string{
-		"bad", "terrible", "awful", "hate", "annoying", "broken", "sad",
-		"angry", "fail", "failure", "bug", "crash", "worse", "worst",
+// SetThreshold changes the |score| at which mood leaves neutral. The new
+// threshold is saved and outlasts the configured one.
+func (e *Engine) SetThreshold(t float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.threshold = t
+	e.tuned = true
+	e.refresh()
+}
+
+// State returns the persistable mood state.
+func (e *Engine) State() State {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	affect := make(Affect, len(e.affect))
+	for k, v := range e.affect {
+		affect[k] = v
 	}
+	return State{Score: e.score, Threshold: e.threshold, Tuned: e.tuned, Affect: affect, UpdatedAt: e.lastUpdate}
+}
 
-	score := 0.0
-	for _, p := range positives {
-		if strings.Contains(t, p) {
-			score += 0.5
+// Restore replaces the mood state. Decay resumes from st.UpdatedAt.
+// Dimensions st doesn't carry keep their current value. The score is held
+// within the clamp and the threshold is reset to the configured one unless
+// st was tuned.
+func (e *Engine) Restore(st State) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.score = max(-e.clamp, min(e.clamp, st.Score))
+	for name, v := range st.Affect {
+		if _, ok := e.dims[name]; ok {
+			e.affect[name] = max(0, min(1, v))
 		}
 	}
-	for _, n := range negatives {
-		if strings.Contains(t, n) {
-			score -= 0.5
-		}
+	e.threshold, e.tuned = e.configured, false
+	if st.Tuned && st.Threshold > 0 && st.Threshold < e.clamp {
+		e.threshold, e.tuned = st.Threshold, true
 	}
+	if !st.UpdatedAt.IsZero() {
+		e.lastUpdate = st.UpdatedAt
+	}
+	e.refresh()
+}
 
-	// Amplifier for !!!
-	if strings.Contains(t, "!!!") {
-		if score > 0 {
-			score += 0.25
-		} else if score < 0 {
-			score -= 0.25
-		}
+// Save persists the mood state using AtomicWriteJSON.
+func (e *Engine) Save(path string) error {
+	st := e.State()
+	return storage.AtomicWriteJSON(path, &st)
+}
+
+// Load restores the mood state from disk, if present.
+func (e *Engine) Load(path string) error {
+	if !storage.Exists(path) {
+		return nil
 	}
-	// Soften negative if phrased as a question
-	if strings.HasSuffix(t, "?") && score < 0 {
-		score *= 0.8
+	var st State
+	if err := storage.ReadJSON(path, &st); err != nil {
+		return err
 	}
-	return score
+	e.Restore(st)
+	return nil
 }
 
 func max(a, b float64) float64 {
@@ -1,3 +1,103 @@
 package policy
 
-// Curiosity-based policy.
+import (
+	"fmt"
+	"strings"
+
+	"neon/internal/persona"
+	"neon/internal/storage"
+)
+
+// Curiosity scores inputs by how novel and surprising they are. When the
+// score is high enough, the agent asks about unknown words instead of
+// answering from what it already knows.
+type Curiosity struct {
+	threshold      float64
+	noveltyWeight  float64
+	surpriseWeight float64
+}
+
+// CuriosityScore is the result of evaluating one input.
+type CuriosityScore struct {
+	Score    float64  `json:"score"`    // weighted blend, 0..1
+	Novelty  float64  `json:"novelty"`  // share of tokens never seen before
+	Surprise float64  `json:"surprise"` // world-model prediction error, 0..1
+	Unknown  []string `json:"unknown"`  // tokens never seen before, in input order
+}
+
+// NewCuriosity creates a curiosity policy that asks questions once the
+// score reaches threshold (e.g. 0.5).
+func NewCuriosity(threshold float64) *Curiosity {
+	if threshold <= 0 {
+		threshold = 0.5
+	}
+	return &Curiosity{
+		threshold:      threshold,
+		noveltyWeight:  0.6,
+		surpriseWeight: 0.4,
+	}
+}
+
+// Evaluate scores input. known reports whether a word has been seen before;
+// surprise is the world model's prediction error for this turn (0 if none).
+func (c *Curiosity) Evaluate(input string, known func(word string) bool, surprise float64) CuriosityScore {
+	tokens := storage.Tokenize(input)
+	s := CuriosityScore{Surprise: clamp01(surprise)}
+
+	distinct := make(map[string]bool)
+	for _, t := range tokens {
+		if distinct[t] {
+			continue
+		}
+		distinct[t] = true
+		if !known(t) {
+			s.Unknown = append(s.Unknown, t)
+		}
+	}
+	if len(distinct) > 0 {
+		s.Novelty = float64(len(s.Unknown)) / float64(len(distinct))
+	}
+	s.Score = c.noveltyWeight*s.Novelty + c.surpriseWeight*s.Surprise
+	return s
+}
+
+// Curious reports whether s is high enough to ask a question.
+func (c *Curiosity) Curious(s CuriosityScore) bool {
+	return s.Score >= c.threshold && len(s.Unknown) > 0
+}
+
+// Question returns a clarifying question about the unknown words in s, or
+// "" if the agent isn't curious enough to ask.
+func (c *Curiosity) Question(mood persona.Mood, s CuriosityScore) string {
+	if !c.Curious(s) {
+		return ""
+	}
+	word := s.Unknown[0]
+	for _, w := range s.Unknown[1:] {
+		// Longer words are more likely to carry meaning than "a" or "the".
+		if len(w) > len(word) {
+			word = w
+		}
+	}
+	if len(s.Unknown) == 1 {
+		return fmt.Sprintf("(%s) What does '%s' mean?", mood, word)
+	}
+	others := make([]string, 0, len(s.Unknown)-1)
+	for _, w := range s.Unknown {
+		if w != word {
+			others = append(others, "'"+w+"'")
+		}
+	}
+	return fmt.Sprintf("(%s) What does '%s' mean? I haven't seen %s before either.",
+		mood, word, strings.Join(others, ", "))
+}
+
+func clamp01(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
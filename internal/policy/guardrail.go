@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AgentAuthor marks rules the agent wrote itself. Rules with no author
+// predate authorship tracking and were all written by the agent.
+const AgentAuthor = "agent"
+
+// Guardrails limit the changes the agent may make to its own policy.
+// Changes made by people, through proposals, are only held to Deny.
+type Guardrails struct {
+	MaxRules          int      // cap on the number of rules (0 = no cap)
+	MaxEditsPerMinute int      // cap on agent edits to any one rule (0 = no cap)
+	Deny              []string // words that may never become triggers
+}
+
+// Violation is the error returned when a change breaks a guardrail.
+type Violation struct {
+	Guardrail string `json:"guardrail"` // max_rules | edit_rate | denylist | protected
+	Word      string `json:"word"`
+	Detail    string `json:"detail"`
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("guardrail %s: %s", v.Guardrail, v.Detail)
+}
+
+// Guarded reports whether the agent must leave r alone: it is marked
+// protected or was written by a person.
+func (r *Rule) Guarded() bool {
+	return r.Protected || (r.Author != "" && r.Author != AgentAuthor)
+}
+
+// SetGuardrails replaces the engine's guardrails.
+func (e *Engine) SetGuardrails(g Guardrails) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.guard = g
+}
+
+// CheckTrigger rejects words on the denylist.
+func (e *Engine) CheckTrigger(word string) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.checkTrigger(word)
+}
+
+// CheckAgentChange reports whether the guardrails rule out any agent
+// change to the rule for word: a denylisted trigger, or an existing rule
+// that is guarded. The caps are only checked when a change is made.
+func (e *Engine) CheckAgentChange(word string) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if err := e.checkTrigger(word); err != nil {
+		return err
+	}
+	if i := e.indexFor(word); i >= 0 && e.rules[i].Guarded() {
+		return protected(word)
+	}
+	return nil
+}
+
+func (e *Engine) checkTrigger(word string) error {
+	for _, d := range e.guard.Deny {
+		if strings.EqualFold(d, word) {
+			return &Violation{Guardrail: "denylist", Word: word,
+				Detail: fmt.Sprintf("'%s' may not be a rule trigger", word)}
+		}
+	}
+	return nil
+}
+
+// AddAgentRule adds a rule on the agent's behalf, subject to the
+// guardrails, stamping it like AddRule. A rejected rule is returned as a
+// *Violation.
+func (e *Engine) AddAgentRule(rule Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.checkTrigger(rule.When.Word); err != nil {
+		return err
+	}
+	if e.guard.MaxRules > 0 && len(e.rules) >= e.guard.MaxRules {
+		return &Violation{Guardrail: "max_rules", Word: rule.When.Word,
+			Detail: fmt.Sprintf("already at the cap of %d rules", e.guard.MaxRules)}
+	}
+	rule.Author = AgentAuthor
+	if rule.Stats == nil {
+		rule.Stats = &Stats{Created: e.clock.Now().UTC()}
+	}
+	e.rules = append(e.rules, rule)
+	return nil
+}
+
+// EditAgentRule changes the response of the rule for word on the agent's
+// behalf, subject to the guardrails. It reports whether the response
+// changed; a rejected edit is returned as a *Violation. A missing rule or
+// an unchanged response is not an error and doesn't count against the
+// edit rate. Rules for denylisted words, written before the denylist
+// covered them, are never edited.
+func (e *Engine) EditAgentRule(word, newText string, now time.Time) (bool, error) {
+	if err := ValidateThen(newText); err != nil {
+		return false, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	i := e.indexFor(word)
+	if i < 0 {
+		return false, nil
+	}
+	if e.rules[i].Then == newText {
+		return false, nil
+	}
+	if err := e.checkTrigger(word); err != nil {
+		return false, err
+	}
+	if e.rules[i].Guarded() {
+		return false, protected(word)
+	}
+
+	key := strings.ToLower(word)
+	recent := e.edits[key][:0]
+	for _, t := range e.edits[key] {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	if e.guard.MaxEditsPerMinute > 0 && len(recent) >= e.guard.MaxEditsPerMinute {
+		e.edits[key] = recent
+		return false, &Violation{Guardrail: "edit_rate", Word: word,
+			Detail: fmt.Sprintf("the rule for '%s' was already edited %d times in the last minute", word, len(recent))}
+	}
+	if e.edits == nil {
+		e.edits = make(map[string][]time.Time)
+	}
+	e.edits[key] = append(recent, now)
+	e.rules[i].Then = newText
+	return true, nil
+}
+
+func protected(word string) *Violation {
+	return &Violation{Guardrail: "protected", Word: word,
+		Detail: fmt.Sprintf("the rule for '%s' is protected", word)}
+}
+
+// indexFor returns the index of the rule for word, or -1. Caller must
+// hold e.mu.
+func (e *Engine) indexFor(word string) int {
+	for i, r := range e.rules {
+		if strings.EqualFold(r.When.Word, word) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"neon/internal/storage"
+)
+
+// Version is one saved rule set in the policy history.
+type Version struct {
+	N     int       `json:"n"`
+	Path  string    `json:"path"`
+	Saved time.Time `json:"saved"`
+	Rules int       `json:"rules"`
+}
+
+// historyDir holds policy-<n>.json files next to the live rules file.
+func (e *Engine) historyDir() string {
+	return filepath.Join(filepath.Dir(e.path), "history")
+}
+
+func (e *Engine) versionPath(n int) string {
+	return filepath.Join(e.historyDir(), fmt.Sprintf("policy-%06d.json", n))
+}
+
+// versions lists the version numbers on disk in ascending order.
+func (e *Engine) versions() ([]int, error) {
+	files, err := filepath.Glob(filepath.Join(e.historyDir(), "policy-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var out []int
+	for _, f := range files {
+		base := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "policy-"), ".json")
+		if n, err := strconv.Atoi(base); err == nil {
+			out = append(out, n)
+		}
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// initHistory picks up the latest version on disk. Rules that predate the
+// history are recorded as its first version. Caller must hold e.mu.
+func (e *Engine) initHistory() error {
+	data, err := json.Marshal(e.stored())
+	if err != nil {
+		return err
+	}
+	e.saved = data
+	if e.defs, err = definitions(e.rules); err != nil {
+		return err
+	}
+
+	vs, err := e.versions()
+	if err != nil {
+		return err
+	}
+	if len(vs) > 0 {
+		e.version = vs[len(vs)-1]
+		return nil
+	}
+	if storage.Exists(e.path) {
+		return e.appendVersion()
+	}
+	return nil
+}
+
+// appendVersion records the current rules as the next version.
+// Caller must hold e.mu.
+func (e *Engine) appendVersion() error {
+	if err := os.MkdirAll(e.historyDir(), 0o755); err != nil {
+		return err
+	}
+	if err := storage.AtomicWriteJSON(e.versionPath(e.version+1), e.rules); err != nil {
+		return err
+	}
+	e.version++
+	return nil
+}
+
+// History returns every saved version, oldest first.
+func (e *Engine) History() ([]Version, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	vs, err := e.versions()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Version, 0, len(vs))
+	for _, n := range vs {
+		v := Version{N: n, Path: e.versionPath(n)}
+		if st, err := os.Stat(v.Path); err == nil {
+			v.Saved = st.ModTime()
+		}
+		if rules, _, err := LoadRules(v.Path); err == nil {
+			v.Rules = len(rules)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// Revert replaces the rules with those of version n and saves them, which
+// records the reverted set as a new version; history is never rewritten.
+// Rules of that version that are no longer valid are left out and
+// returned in skipped.
+func (e *Engine) Revert(n int) (skipped []error, err error) {
+	e.mu.RLock()
+	path := e.versionPath(n)
+	e.mu.RUnlock()
+
+	if !storage.Exists(path) {
+		return nil, fmt.Errorf("no policy version %d", n)
+	}
+	rules, skipped, err := LoadRules(path)
+	if err != nil {
+		return nil, fmt.Errorf("policy version %d: %w", n, err)
+	}
+	e.SetRules(rules)
+	return skipped, e.Save()
+}
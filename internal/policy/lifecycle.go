@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Stats tracks a rule's lifecycle. Rules written before stats existed
+// have none; they are given a creation time the first time GC sees them,
+// even on a dry run.
+type Stats struct {
+	Created   time.Time `json:"created"`
+	LastFired time.Time `json:"last_fired"`
+	Hits      int       `json:"hits"`
+}
+
+// GCPolicy decides which rules a GC pass retires.
+type GCPolicy struct {
+	MinAge time.Duration // grace period before a rule that never fired is retired
+	Idle   time.Duration // retire rules off the top set that haven't fired for this long
+}
+
+// Retirement is a rule removed by GC and why.
+type Retirement struct {
+	Rule   Rule   `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// Fire finds the rule that wins for the input and records a hit on it.
+// The trace explains the decision either way.
+func (e *Engine) Fire(in Input, now time.Time) (Rule, Trace, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t := e.trace(in)
+	i := t.Winner
+	if i < 0 {
+		return Rule{}, t, false
+	}
+	// Copy on write: rules handed out by Rules share Stats.
+	st := Stats{Created: now}
+	if e.rules[i].Stats != nil {
+		st = *e.rules[i].Stats
+	}
+	st.Hits++
+	st.LastFired = now
+	e.rules[i].Stats = &st
+	return e.rules[i], t, true
+}
+
+// GC retires rules that have outlived their usefulness: rules that never
+// fired within p.MinAge of being created, and word rules whose word is no
+// longer in top and that haven't fired for p.Idle. Rules without a trigger
+// word are only retired for never firing, and guarded rules never are.
+// With dryRun set nothing is removed. It returns the rules retired (or
+// that would be retired).
+func (e *Engine) GC(p GCPolicy, top []string, now time.Time, dryRun bool) []Retirement {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	inTop := make(map[string]bool, len(top))
+	for _, w := range top {
+		inTop[w] = true
+	}
+
+	var retired []Retirement
+	kept := make([]Rule, 0, len(e.rules))
+	for i := range e.rules {
+		if e.rules[i].Stats == nil {
+			e.rules[i].Stats = &Stats{Created: now}
+		}
+		r := e.rules[i]
+		reason := ""
+		switch {
+		case r.Guarded():
+		case r.Stats.Hits == 0 && now.Sub(r.Stats.Created) >= p.MinAge:
+			reason = "never fired"
+		case r.When.Word != "" && !inTop[r.When.Word] &&
+			now.Sub(lastActive(r.Stats)) >= p.Idle:
+			reason = "word left the top set"
+		}
+		if reason != "" {
+			retired = append(retired, Retirement{Rule: r, Reason: reason})
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if !dryRun {
+		e.rules = kept
+	}
+	return retired
+}
+
+// lastActive is when the rule last fired, or was created if it never has.
+func lastActive(s *Stats) time.Time {
+	if s.LastFired.After(s.Created) {
+		return s.LastFired
+	}
+	return s.Created
+}
+
+// definitions encodes rules without their stats, so that history only
+// records changes to what the rules do, not every hit.
+func definitions(rules []Rule) ([]byte, error) {
+	defs := make([]Rule, len(rules))
+	for i, r := range rules {
+		r.Stats = nil
+		defs[i] = r
+	}
+	return json.Marshal(defs)
+}
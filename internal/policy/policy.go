@@ -1,85 +1,206 @@
 package policy
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
-	"neon/internal/persona"
+	"neon/internal/clock"
+	"neon/internal/storage"
 )
 
-// Rule defines a simple if-then behavior.
-// Conditions: mood + word presence
+// Rule defines an if-then behavior.
+// Conditions: see Condition (mood, word, score range, AND/OR/NOT groups)
 // Action: override or modify response
+// When several rules match, the highest Priority wins, then the most
+// specific condition, then the earliest rule.
 type Rule struct {
-	When struct {
-		Mood string `json:"mood"`
-		Word string `json:"word"`
-	} `json:"when"`
-	Then string `json:"then"` // text template
+	When     Condition `json:"when"`
+	Then     string    `json:"then"` // text/template over Vars
+	Priority int       `json:"priority,omitempty"`
+
+	Author    string `json:"author,omitempty"`    // AgentAuthor, or who approved it
+	Protected bool   `json:"protected,omitempty"` // never edited or retired by the agent
+	Stats     *Stats `json:"stats,omitempty"`
 }
 
 type Engine struct {
 	mu    sync.RWMutex
 	rules []Rule
 	path  string
+	clock clock.Clock // stamps new rules
+	re    regexCache
+	tmpl  templateCache
+
+	// Guardrails on agent-authored changes (see guardrail.go).
+	guard Guardrails
+	edits map[string][]time.Time // recent agent edits per rule word
+
+	// History: every distinct rule set saved is also kept as a numbered
+	// version alongside path (see history.go).
+	version int    // latest version number on disk
+	saved   []byte // encoding of the rules last written
+	defs    []byte // encoding of the rules' definitions in the latest version
+
+	// What Load couldn't use. Skipped rules are kept as they were on disk
+	// and written back by Save, so they can still be fixed by hand; while
+	// the file itself failed to load, Save refuses to overwrite it.
+	invalid []json.RawMessage
+	skipped []error
+	loadErr error
 }
 
 // NewEngine loads rules from a JSON file, or creates empty if not found.
-func NewEngine(path string) *Engine {
-	e := &Engine{path: path}
+// New rules are stamped with clk, and no guardrails apply until
+// SetGuardrails. If the file can't be loaded the engine starts without
+// rules but won't save over it; see Err and Skipped.
+func NewEngine(path string, clk clock.Clock) *Engine {
+	e := &Engine{path: path, clock: clk, rules: []Rule{}}
 	_ = e.Load()
 	return e
 }
 
+// Load reads the rules file, leaving out any rule that fails validation.
 func (e *Engine) Load() error {
+	rules, invalid, skipped, err := readRules(e.path)
 	e.mu.Lock()
 	defer e.mu.Unlock()
-
-	f, err := os.ReadFile(e.path)
-	if err != nil {
-		e.rules = []Rule{}
-		return nil
-	}
-	var rules []Rule
-	if err := json.Unmarshal(f, &rules); err != nil {
+	if e.loadErr = err; err != nil {
 		return err
 	}
-	e.rules = rules
-	return nil
+	e.rules, e.invalid, e.skipped = rules, invalid, skipped
+	return e.initHistory()
 }
 
-func (e *Engine) Save() error {
+// Err returns why the rules file couldn't be loaded, if it couldn't.
+func (e *Engine) Err() error {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
+	return e.loadErr
+}
+
+// Skipped returns an error for each rule Load left out as invalid.
+func (e *Engine) Skipped() []error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]error(nil), e.skipped...)
+}
 
-	data, err := json.MarshalIndent(e.rules, "", "  ")
+// LoadRules reads a rules file. A missing file yields no rules; a file
+// that exists but can't be decoded is an error. Invalid rules are left
+// out and reported in skipped, as by DecodeRules.
+func LoadRules(path string) (rules []Rule, skipped []error, err error) {
+	rules, _, skipped, err = readRules(path)
+	return rules, skipped, err
+}
+
+func readRules(path string) ([]Rule, []json.RawMessage, []error, error) {
+	f, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		if os.IsNotExist(err) {
+			return []Rule{}, nil, nil, nil
+		}
+		return nil, nil, nil, err
 	}
-	return os.WriteFile(e.path, data, 0o644)
+	return decodeRules(f)
 }
 
-// Apply checks rules against mood + input text, may return an override response.
-func (e *Engine) Apply(mood persona.Mood, input string) string {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+// DecodeRules decodes and validates rules as written by Save. A rule that
+// can't be decoded or fails validation doesn't cost the others: it is left
+// out and reported in skipped. err is only set when data isn't a list of
+// rules at all.
+func DecodeRules(data []byte) (rules []Rule, skipped []error, err error) {
+	rules, _, skipped, err = decodeRules(data)
+	return rules, skipped, err
+}
 
-	for _, r := range e.rules {
-		if (r.When.Mood == "" || strings.EqualFold(r.When.Mood, string(mood))) &&
-			(r.When.Word == "" || strings.Contains(strings.ToLower(input), strings.ToLower(r.When.Word))) {
-			return r.Then
+// decodeRules is DecodeRules that also returns the skipped rules as
+// they were encoded.
+func decodeRules(data []byte) ([]Rule, []json.RawMessage, []error, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, nil, err
+	}
+	rules := make([]Rule, 0, len(raw))
+	var invalid []json.RawMessage
+	var skipped []error
+	for i, r := range raw {
+		var rule Rule
+		if err := json.Unmarshal(r, &rule); err != nil {
+			invalid = append(invalid, r)
+			skipped = append(skipped, fmt.Errorf("rule %d: %w", i, err))
+			continue
+		}
+		// Name the rule by its trigger too: a response template that no
+		// longer parses (say, literal braces in a rule written before
+		// responses were templates) is easier to find that way.
+		if err := rule.Validate(); err != nil {
+			invalid = append(invalid, r)
+			if rule.When.Word != "" {
+				err = fmt.Errorf("%s: %w", rule.When.wordTest(), err)
+			}
+			skipped = append(skipped, fmt.Errorf("rule %d: %w", i, err))
+			continue
 		}
+		rules = append(rules, rule)
 	}
-	return ""
+	return rules, invalid, skipped, nil
 }
 
-// AddRule appends a new rule.
-func (e *Engine) AddRule(rule Rule) {
+// Save writes the rules atomically and, if their definitions differ from
+// the latest version, records them as a new history version. Changes to
+// rule stats alone don't create a version.
+func (e *Engine) Save() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.loadErr != nil {
+		return fmt.Errorf("not overwriting %s, which failed to load: %w", e.path, e.loadErr)
+	}
+	stored := e.stored()
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, e.saved) && storage.Exists(e.path) {
+		return nil
+	}
+	defs, err := definitions(e.rules)
+	if err != nil {
+		return err
+	}
+	if err := storage.AtomicWriteJSON(e.path, stored); err != nil {
+		return err
+	}
+	// Only count the rules as saved once their version is recorded too,
+	// so a failed history write is retried by the next Save.
+	if !bytes.Equal(defs, e.defs) {
+		if err := e.appendVersion(); err != nil {
+			return err
+		}
+		e.defs = defs
+	}
+	e.saved = data
+	return nil
+}
+
+// AddRule validates and appends a new rule, stamping its creation time
+// unless it already carries stats.
+func (e *Engine) AddRule(rule Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if rule.Stats == nil {
+		rule.Stats = &Stats{Created: e.clock.Now().UTC()}
+	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.rules = append(e.rules, rule)
+	return nil
 }
 
 // HasRuleFor checks if a rule already exists for a given word.
@@ -94,13 +215,70 @@ func (e *Engine) HasRuleFor(word string) bool {
 	return false
 }
 
+// RuleFor returns the rule for a given word (if rule exists).
+func (e *Engine) RuleFor(word string) (Rule, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, r := range e.rules {
+		if strings.EqualFold(r.When.Word, word) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
 // UpdateRule modifies the response text for a given word (if rule exists).
 func (e *Engine) UpdateRule(word, newText string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if i := e.indexFor(word); i >= 0 {
+		e.rules[i].Then = newText
+		return true
+	}
+	return false
+}
+
+// Rules returns a copy of the current rules.
+func (e *Engine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	out := make([]Rule, len(e.rules))
+	copy(out, e.rules)
+	return out
+}
+
+// stored is what Save writes: the rules followed by any Load skipped.
+// Caller must hold e.mu.
+func (e *Engine) stored() any {
+	if len(e.invalid) == 0 {
+		return e.rules
+	}
+	out := make([]any, 0, len(e.rules)+len(e.invalid))
+	for _, r := range e.rules {
+		out = append(out, r)
+	}
+	for _, r := range e.invalid {
+		out = append(out, r)
+	}
+	return out
+}
+
+// SetRules replaces all rules, including any Load skipped, and allows
+// Save to write them even if the rules file failed to load.
+func (e *Engine) SetRules(rules []Rule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = append([]Rule{}, rules...)
+	e.invalid, e.skipped, e.loadErr = nil, nil, nil
+}
+
+// DeleteRule removes the rule for a given word (if rule exists).
+func (e *Engine) DeleteRule(word string) bool {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	for i, r := range e.rules {
 		if strings.EqualFold(r.When.Word, word) {
-			e.rules[i].Then = newText
+			e.rules = append(e.rules[:i], e.rules[i+1:]...)
 			return true
 		}
 	}
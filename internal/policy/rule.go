@@ -0,0 +1,305 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"neon/internal/persona"
+	"neon/internal/storage"
+)
+
+// Match kinds for Condition.Word.
+const (
+	MatchSubstring = "substring" // default: word appears anywhere in the input
+	MatchWord      = "word"      // word (or phrase) appears as whole tokens
+	MatchRegex     = "regex"     // word is a case-insensitive regular expression
+)
+
+// Condition is a rule's "when" clause. Every test that is set must pass
+// (AND); All adds further conditions that must all match, Any a group of
+// which at least one must match. Not inverts the whole condition. Affect
+// bounds affect dimensions by name, e.g. {"frustration": {"min": 0.5}}.
+//
+// The legacy rule format {"mood": "...", "word": "..."} is a Condition
+// with just Mood and Word set, so existing policy files load unchanged.
+type Condition struct {
+	Mood     string           `json:"mood,omitempty"`
+	Word     string           `json:"word,omitempty"`
+	Match    string           `json:"match,omitempty"`     // substring (default) | word | regex
+	MinScore *float64         `json:"min_score,omitempty"` // mood score >= MinScore
+	MaxScore *float64         `json:"max_score,omitempty"` // mood score <= MaxScore
+	Affect   map[string]Range `json:"affect,omitempty"`
+	Not      bool             `json:"not,omitempty"`
+	All      []Condition      `json:"all,omitempty"`
+	Any      []Condition      `json:"any,omitempty"`
+}
+
+// Range bounds a value; either end may be left open.
+type Range struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// Input is what rules are evaluated against. Top and Turn are only used
+// when rendering the winning rule's response.
+type Input struct {
+	Mood   persona.Mood
+	Score  float64
+	Affect persona.Affect
+	Text   string
+	Top    []string
+	Turn   int
+}
+
+// Validate checks that the condition (and any nested ones) is well formed.
+func (c *Condition) Validate() error {
+	switch c.Match {
+	case "", MatchSubstring, MatchWord:
+	case MatchRegex:
+		if _, err := regexp.Compile("(?i)" + c.Word); err != nil {
+			return fmt.Errorf("bad regex %q: %w", c.Word, err)
+		}
+	default:
+		return fmt.Errorf("unknown match kind %q", c.Match)
+	}
+	if c.MinScore != nil && c.MaxScore != nil && *c.MinScore > *c.MaxScore {
+		return fmt.Errorf("min_score %g above max_score %g", *c.MinScore, *c.MaxScore)
+	}
+	for dim, r := range c.Affect {
+		switch {
+		case !persona.IsDimension(dim):
+			return fmt.Errorf("unknown affect dimension %q", dim)
+		case r.Min == nil && r.Max == nil:
+			return fmt.Errorf("affect %s has no bounds", dim)
+		case r.Min != nil && r.Max != nil && *r.Min > *r.Max:
+			return fmt.Errorf("affect %s min %g above max %g", dim, *r.Min, *r.Max)
+		}
+	}
+	for i := range c.All {
+		if err := c.All[i].Validate(); err != nil {
+			return err
+		}
+	}
+	for i := range c.Any {
+		if err := c.Any[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks that the rule can be evaluated.
+func (r *Rule) Validate() error {
+	if err := r.When.Validate(); err != nil {
+		return err
+	}
+	if r.Then == "" {
+		return errors.New("rule has no response")
+	}
+	return ValidateThen(r.Then)
+}
+
+// matches reports whether in satisfies c, appending every test it makes
+// to checks with its name prefixed by path.
+func (c *Condition) matches(in Input, cache *regexCache, checks *[]Check, path string) bool {
+	ok := c.eval(in, cache, checks, path) != c.Not
+	if c.Not {
+		*checks = append(*checks, Check{Test: path + "not", Pass: ok})
+	}
+	return ok
+}
+
+// eval evaluates every test in c, without short-circuiting, so a trace
+// shows all the reasons a rule did or didn't match.
+func (c *Condition) eval(in Input, cache *regexCache, checks *[]Check, path string) bool {
+	pass := true
+	test := func(name string, ok bool) {
+		*checks = append(*checks, Check{Test: path + name, Pass: ok})
+		pass = pass && ok
+	}
+
+	if c.Mood != "" {
+		test("mood="+c.Mood, strings.EqualFold(c.Mood, string(in.Mood)))
+	}
+	if c.MinScore != nil {
+		test(fmt.Sprintf("score>=%g", *c.MinScore), in.Score >= *c.MinScore)
+	}
+	if c.MaxScore != nil {
+		test(fmt.Sprintf("score<=%g", *c.MaxScore), in.Score <= *c.MaxScore)
+	}
+	for _, dim := range c.affectDims() {
+		r, v := c.Affect[dim], in.Affect[dim]
+		if r.Min != nil {
+			test(fmt.Sprintf("%s>=%g", dim, *r.Min), v >= *r.Min)
+		}
+		if r.Max != nil {
+			test(fmt.Sprintf("%s<=%g", dim, *r.Max), v <= *r.Max)
+		}
+	}
+	if c.Word != "" {
+		test(c.wordTest(), c.matchWord(in.Text, cache))
+	}
+	for i := range c.All {
+		name := fmt.Sprintf("all[%d]", i)
+		test(name, c.All[i].matches(in, cache, checks, path+name+"."))
+	}
+	if len(c.Any) > 0 {
+		any := false
+		for i := range c.Any {
+			if c.Any[i].matches(in, cache, checks, fmt.Sprintf("%sany[%d].", path, i)) {
+				any = true
+			}
+		}
+		test("any", any)
+	}
+	return pass
+}
+
+// affectDims returns the dimensions c bounds, in a fixed order.
+func (c *Condition) affectDims() []string {
+	var dims []string
+	for _, d := range persona.Dimensions {
+		if _, ok := c.Affect[d]; ok {
+			dims = append(dims, d)
+		}
+	}
+	return dims
+}
+
+// wordTest names c's word test for traces.
+func (c *Condition) wordTest() string {
+	switch c.Match {
+	case MatchWord:
+		return fmt.Sprintf("word=%q", c.Word)
+	case MatchRegex:
+		return fmt.Sprintf("word=/%s/", c.Word)
+	default:
+		return fmt.Sprintf("word~%q", c.Word)
+	}
+}
+
+func (c *Condition) matchWord(text string, cache *regexCache) bool {
+	switch c.Match {
+	case MatchWord:
+		return containsTokens(storage.Tokenize(text), storage.Tokenize(c.Word))
+	case MatchRegex:
+		re := cache.get(c.Word)
+		return re != nil && re.MatchString(text)
+	default:
+		return strings.Contains(strings.ToLower(text), strings.ToLower(c.Word))
+	}
+}
+
+// matchedWord returns the text that satisfied the first word test in c
+// (the regex match for regex conditions), or "" if none did. Negated
+// conditions never supply a word.
+func (c *Condition) matchedWord(text string, cache *regexCache) string {
+	if c.Not {
+		return ""
+	}
+	if c.Word != "" && c.matchWord(text, cache) {
+		if c.Match == MatchRegex {
+			if re := cache.get(c.Word); re != nil {
+				return re.FindString(text)
+			}
+		}
+		return c.Word
+	}
+	for _, group := range [][]Condition{c.All, c.Any} {
+		for i := range group {
+			if w := group[i].matchedWord(text, cache); w != "" {
+				return w
+			}
+		}
+	}
+	return ""
+}
+
+// Specificity scores how narrow a condition is, for breaking ties between
+// rules of equal priority: each test counts one, whole-word and regex
+// matches count one more than a substring, and nested groups add theirs.
+// An Any group counts as its least specific branch.
+func (c *Condition) Specificity() int {
+	n := 0
+	if c.Mood != "" {
+		n++
+	}
+	if c.Word != "" {
+		n++
+		if c.Match == MatchWord || c.Match == MatchRegex {
+			n++
+		}
+	}
+	if c.MinScore != nil {
+		n++
+	}
+	if c.MaxScore != nil {
+		n++
+	}
+	for _, r := range c.Affect {
+		if r.Min != nil {
+			n++
+		}
+		if r.Max != nil {
+			n++
+		}
+	}
+	for i := range c.All {
+		n += c.All[i].Specificity()
+	}
+	if len(c.Any) > 0 {
+		least := c.Any[0].Specificity()
+		for i := range c.Any[1:] {
+			least = min(least, c.Any[i+1].Specificity())
+		}
+		n += least
+	}
+	return n
+}
+
+// containsTokens reports whether needle appears as a contiguous run in hay.
+func containsTokens(hay, needle []string) bool {
+	if len(needle) == 0 {
+		return false
+	}
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		match := true
+		for j := range needle {
+			if hay[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// regexCache compiles each pattern once. It is safe for concurrent use,
+// since rules are matched under Engine's read lock.
+type regexCache struct {
+	mu       sync.Mutex
+	compiled map[string]*regexp.Regexp
+}
+
+func (rc *regexCache) get(pattern string) *regexp.Regexp {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if re, ok := rc.compiled[pattern]; ok {
+		return re
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		re = nil
+	}
+	if rc.compiled == nil {
+		rc.compiled = make(map[string]*regexp.Regexp)
+	}
+	rc.compiled[pattern] = re
+	return re
+}
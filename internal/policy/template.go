@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+
+	"neon/internal/persona"
+)
+
+// Vars are the values a rule's Then template can refer to, e.g.
+//
+//	"Feeling {{.Mood}} ({{printf \"%.1f\" .Score}}) about '{{.Word}}'."
+//	"You keep saying {{join .Top \", \"}}."
+//	"{{if gt .Affect.curiosity 0.5}}Tell me more!{{end}}"
+type Vars struct {
+	Word   string             // the word (or regex match) that triggered the rule
+	Mood   string             // current discrete mood
+	Score  float64            // current mood score
+	Affect map[string]float64 // affect dimension → value
+	Top    []string           // most frequent words, most frequent first
+	Input  string             // the text being responded to
+	Turn   int                // turn number within this run
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// sampleVars is used to execute templates once at validation time, so
+// references to unknown fields fail when a rule is added or loaded
+// rather than when it fires.
+var sampleVars = Vars{
+	Word:   "word",
+	Mood:   "neutral",
+	Affect: sampleAffect(),
+	Top:    []string{"word", "word", "word", "word", "word"},
+	Input:  "word",
+	Turn:   1,
+}
+
+func sampleAffect() map[string]float64 {
+	a := make(map[string]float64, len(persona.Dimensions))
+	for _, d := range persona.Dimensions {
+		a[d] = 0
+	}
+	return a
+}
+
+// ValidateThen checks that then parses and executes as a response template.
+func ValidateThen(then string) error {
+	t, err := parseThen(then)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(io.Discard, sampleVars); err != nil {
+		return fmt.Errorf("bad response template: %w", err)
+	}
+	return nil
+}
+
+func parseThen(then string) (*template.Template, error) {
+	t, err := template.New("then").Funcs(templateFuncs).Option("missingkey=error").Parse(then)
+	if err != nil {
+		return nil, fmt.Errorf("bad response template: %w", err)
+	}
+	return t, nil
+}
+
+// Render expands r.Then for the input that matched it. If the template
+// fails to execute, the literal text is returned along with the error.
+func (e *Engine) Render(r Rule, in Input) (string, error) {
+	t, err := e.tmpl.get(r.Then)
+	if err != nil {
+		return r.Then, err
+	}
+	v := Vars{
+		Word:   r.When.matchedWord(in.Text, &e.re),
+		Mood:   string(in.Mood),
+		Score:  in.Score,
+		Affect: in.Affect,
+		Top:    in.Top,
+		Input:  in.Text,
+		Turn:   in.Turn,
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, v); err != nil {
+		return r.Then, fmt.Errorf("render rule for %q: %w", v.Word, err)
+	}
+	return b.String(), nil
+}
+
+// templateCache parses each response template once. It is safe for
+// concurrent use.
+type templateCache struct {
+	mu     sync.Mutex
+	parsed map[string]*template.Template
+}
+
+func (tc *templateCache) get(then string) (*template.Template, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if t, ok := tc.parsed[then]; ok {
+		return t, nil
+	}
+	t, err := parseThen(then)
+	if err != nil {
+		return nil, err
+	}
+	if tc.parsed == nil {
+		tc.parsed = make(map[string]*template.Template)
+	}
+	tc.parsed[then] = t
+	return t, nil
+}
@@ -0,0 +1,149 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Check is one test made while evaluating a rule's condition, named by
+// its path within the condition, e.g. "mood=positive" or "any[1].word~\"x\"".
+type Check struct {
+	Test string `json:"test"`
+	Pass bool   `json:"pass"`
+}
+
+// RuleTrace records how one rule fared against an input.
+type RuleTrace struct {
+	Index       int     `json:"index"`
+	Word        string  `json:"word,omitempty"`
+	Priority    int     `json:"priority,omitempty"`
+	Specificity int     `json:"specificity"`
+	Matched     bool    `json:"matched"`
+	Checks      []Check `json:"checks"`
+}
+
+// Trace explains a policy decision: every rule evaluated and which one
+// won. Winner indexes the rule list at the time of the decision, or is -1
+// if no rule matched; each RuleTrace carries its own Index, so Rules may
+// be trimmed (see Trim).
+type Trace struct {
+	Evaluated int         `json:"evaluated"`
+	Rules     []RuleTrace `json:"rules"`
+	Winner    int         `json:"winner"`
+}
+
+// trace evaluates all rules and picks the winner: highest priority, then
+// most specific, then first in the list. Caller must hold e.mu.
+func (e *Engine) trace(in Input) Trace {
+	t := Trace{Evaluated: len(e.rules), Rules: make([]RuleTrace, 0, len(e.rules)), Winner: -1}
+	for i := range e.rules {
+		r := &e.rules[i]
+		rt := RuleTrace{
+			Index:       i,
+			Word:        r.When.Word,
+			Priority:    r.Priority,
+			Specificity: r.When.Specificity(),
+		}
+		rt.Matched = r.When.matches(in, &e.re, &rt.Checks, "")
+		t.Rules = append(t.Rules, rt)
+
+		if !rt.Matched {
+			continue
+		}
+		if t.Winner < 0 {
+			t.Winner = i
+			continue
+		}
+		best := t.Rules[t.Winner]
+		if rt.Priority > best.Priority ||
+			(rt.Priority == best.Priority && rt.Specificity > best.Specificity) {
+			t.Winner = i
+		}
+	}
+	return t
+}
+
+// Trim returns the trace with every rule that matched but at most n of
+// those that didn't: the near misses, which passed the most tests. Rules
+// that failed every test say nothing about the decision and are dropped.
+// Evaluated still counts every rule.
+func (t Trace) Trim(n int) Trace {
+	var matched, missed []RuleTrace
+	for _, rt := range t.Rules {
+		switch {
+		case rt.Matched:
+			matched = append(matched, rt)
+		case rt.passed() > 0:
+			missed = append(missed, rt)
+		}
+	}
+	sort.SliceStable(missed, func(i, j int) bool { return missed[i].passed() > missed[j].passed() })
+	if len(missed) > n {
+		missed = missed[:n]
+	}
+	kept := append(matched, missed...)
+	sort.Slice(kept, func(i, j int) bool { return kept[i].Index < kept[j].Index })
+	t.Rules = kept
+	return t
+}
+
+// passed counts the tests rt passed.
+func (rt RuleTrace) passed() int {
+	n := 0
+	for _, c := range rt.Checks {
+		if c.Pass {
+			n++
+		}
+	}
+	return n
+}
+
+// String renders the trace for people: one line per rule that matched or
+// came close, with its failed tests.
+func (t Trace) String() string {
+	if t.Evaluated == 0 {
+		return "No rules were evaluated."
+	}
+	var b strings.Builder
+	matched := 0
+	for _, rt := range t.Rules {
+		if rt.Matched {
+			matched++
+		}
+	}
+	fmt.Fprintf(&b, "%d rules evaluated, %d matched", t.Evaluated, matched)
+	if t.Winner >= 0 {
+		fmt.Fprintf(&b, ", rule #%d won", t.Winner)
+	}
+	b.WriteString(".")
+
+	for _, rt := range t.Rules {
+		// Rules that failed every test are noise.
+		if !rt.Matched && rt.passed() == 0 {
+			continue
+		}
+		var failed []string
+		for _, c := range rt.Checks {
+			if !c.Pass {
+				failed = append(failed, c.Test)
+			}
+		}
+		mark := " "
+		switch {
+		case rt.Index == t.Winner:
+			mark = "*"
+		case rt.Matched:
+			mark = "+"
+		}
+		fmt.Fprintf(&b, "\n%s #%d", mark, rt.Index)
+		if rt.Word != "" {
+			fmt.Fprintf(&b, " '%s'", rt.Word)
+		}
+		fmt.Fprintf(&b, " priority=%d specificity=%d", rt.Priority, rt.Specificity)
+		if len(failed) > 0 {
+			fmt.Fprintf(&b, " failed: %s", strings.Join(failed, ", "))
+		}
+	}
+	return b.String()
+}
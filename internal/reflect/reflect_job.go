@@ -1,3 +1,362 @@
 package reflect
 
-// Reflection job.
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"neon/internal/clock"
+	"neon/internal/storage"
+	"neon/internal/telemetry"
+	"neon/pkg/structs"
+)
+
+// Report is the structured result of one reflection pass over the events
+// logged since the previous pass.
+type Report struct {
+	Timestamp     time.Time        `json:"timestamp"`
+	From          int64            `json:"from"`   // unix seconds of first event covered
+	To            int64            `json:"to"`     // unix seconds of last event covered
+	Cursor        telemetry.Cursor `json:"cursor"` // position in the log covered so far
+	Reason        string           `json:"reason"`
+	Events        int              `json:"events"`
+	Turns         int              `json:"turns"`
+	Mood          MoodStats        `json:"mood"`
+	NewVocabulary []string         `json:"new_vocabulary"`
+	RulesCreated  []string         `json:"rules_created"`
+	RulesEdited   []string         `json:"rules_edited"`
+	RulesRetired  []string         `json:"rules_retired"`
+	PolicyHits    map[string]int   `json:"policy_hits"` // rule trigger word → times fired
+	PolicyHitRate float64          `json:"policy_hit_rate"`
+	Summary       string           `json:"summary"`
+}
+
+// MoodStats describes the mood score trajectory over a window.
+type MoodStats struct {
+	Trajectory []float64      `json:"trajectory"`
+	Start      float64        `json:"start"`
+	End        float64        `json:"end"`
+	Min        float64        `json:"min"`
+	Max        float64        `json:"max"`
+	Mean       float64        `json:"mean"`
+	Moods      map[string]int `json:"moods"` // discrete mood → turns spent in it
+}
+
+// Job periodically reflects on recent activity. It can be driven by a
+// ticker (Start) and/or by turn count (Tick); both funnel into Run.
+type Job struct {
+	mu       sync.Mutex
+	root     string
+	logger   *telemetry.Logger
+	clock    clock.Clock
+	every    int           // run every N turns (0 disables)
+	interval time.Duration // run every interval (0 disables)
+	turns    int
+	cursor   telemetry.Cursor // events already reflected on
+	known    map[string]bool  // words in the inputs before cursor; nil until needed
+}
+
+// NewJob creates a reflection job reading events under root and writing
+// reports to root/data/reflections. It resumes after the newest existing
+// report so earlier activity isn't reflected on twice. Reports are stamped
+// with the logger's clock.
+func NewJob(root string, logger *telemetry.Logger, every int, interval time.Duration) *Job {
+	clk := clock.System
+	if logger != nil {
+		clk = logger.Clock()
+	}
+	j := &Job{
+		root:     root,
+		logger:   logger,
+		clock:    clk,
+		every:    every,
+		interval: interval,
+	}
+	j.cursor = j.lastCovered()
+	return j
+}
+
+// lastCovered returns how far into the log the reports on disk have
+// covered. Older reports record a count of events covered (through) or
+// only the timestamp of their last event; those are converted once.
+func (j *Job) lastCovered() telemetry.Cursor {
+	files, _ := filepath.Glob(filepath.Join(j.root, "data", "reflections", "reflection-*.json"))
+	covered := make(telemetry.Cursor)
+	through, to := 0, int64(0)
+	for _, f := range files {
+		var r struct {
+			Report
+			Through int `json:"through"`
+		}
+		if err := storage.ReadJSON(f, &r); err != nil {
+			continue
+		}
+		for name, off := range r.Cursor {
+			covered[name] = max(covered[name], off)
+		}
+		if r.Cursor == nil {
+			through, to = max(through, r.Through), max(to, r.To)
+		}
+	}
+	if through > 0 || to > 0 {
+		legacy, _ := telemetry.CursorAfter(telemetry.EventsDir(j.root), func(i int, ev structs.Event) bool {
+			if through > 0 {
+				return i < through
+			}
+			return ev.Timestamp <= to
+		})
+		for name, off := range legacy {
+			covered[name] = max(covered[name], off)
+		}
+	}
+	return covered
+}
+
+// Start runs the job on its interval until ctx is cancelled.
+func (j *Job) Start(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = j.Run("interval")
+			}
+		}
+	}()
+}
+
+// Tick counts a turn and runs the job if the turn cadence is due.
+// It returns the report if one was produced.
+func (j *Job) Tick() (*Report, error) {
+	j.mu.Lock()
+	j.turns++
+	due := j.every > 0 && j.turns%j.every == 0
+	j.mu.Unlock()
+	if !due {
+		return nil, nil
+	}
+	return j.Run("turns")
+}
+
+// Run reflects on all events logged since the previous run, writes the
+// report and summary to data/reflections and logs a REFLECTION event.
+// It returns nil if nothing new has happened.
+func (j *Job) Run(reason string) (*Report, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// Make sure the window includes everything logged up to now.
+	if j.logger != nil {
+		j.logger.Flush()
+	}
+	dir := telemetry.EventsDir(j.root)
+	if j.known == nil {
+		before, err := telemetry.ReadEventsUntil(dir, j.cursor)
+		if err != nil {
+			return nil, err
+		}
+		j.known = make(map[string]bool)
+		learn(j.known, before)
+	}
+	// The window is found by position in the log rather than by timestamp,
+	// which only has one-second resolution and needn't increase: anything
+	// logged after the previous run, even in the same second or stamped
+	// earlier by another process, is in it.
+	logged, cursor, err := telemetry.ReadEventsAfter(dir, j.cursor)
+	if err != nil {
+		return nil, err
+	}
+	var window []structs.Event
+	for _, ev := range logged {
+		if ev.Type != "REFLECTION" {
+			window = append(window, ev)
+		}
+	}
+	j.cursor = cursor
+	if len(window) == 0 {
+		return nil, nil
+	}
+
+	r := analyze(j.known, window)
+	r.Timestamp = j.clock.Now().UTC()
+	r.Reason = reason
+	r.Summary = summarize(r)
+	r.Cursor = cursor
+
+	if err := j.write(r); err != nil {
+		return r, err
+	}
+	if j.logger != nil {
+		j.logger.Log(structs.NewEvent("REFLECTION", "reflect", map[string]any{
+			"reason":          r.Reason,
+			"from":            r.From,
+			"to":              r.To,
+			"turns":           r.Turns,
+			"mood_start":      r.Mood.Start,
+			"mood_end":        r.Mood.End,
+			"new_vocabulary":  len(r.NewVocabulary),
+			"rules_created":   len(r.RulesCreated),
+			"rules_edited":    len(r.RulesEdited),
+			"rules_retired":   len(r.RulesRetired),
+			"policy_hit_rate": r.PolicyHitRate,
+			"summary":         r.Summary,
+		}))
+	}
+	return r, nil
+}
+
+// write saves r as JSON and text. Names have one-second resolution; a
+// numeric suffix keeps a second report in the same second from
+// overwriting the first.
+func (j *Job) write(r *Report) error {
+	dir := filepath.Join(j.root, "data", "reflections")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	name := "reflection-" + r.Timestamp.Format("2006-01-02T15-04-05Z")
+	base := filepath.Join(dir, name)
+	for i := 1; storage.Exists(base + ".json"); i++ {
+		base = filepath.Join(dir, fmt.Sprintf("%s-%d", name, i))
+	}
+	if err := storage.AtomicWriteJSON(base+".json", r); err != nil {
+		return err
+	}
+	return os.WriteFile(base+".txt", []byte(r.Summary+"\n"), 0o644)
+}
+
+// learn adds the words of the inputs among events to known.
+func learn(known map[string]bool, events []structs.Event) {
+	for _, ev := range events {
+		if ev.Type == "INPUT" {
+			for _, w := range storage.Tokenize(payloadString(ev, "text")) {
+				known[w] = true
+			}
+		}
+	}
+}
+
+// analyze computes statistics over window. Words not in known are new;
+// they are added to it.
+func analyze(known map[string]bool, window []structs.Event) *Report {
+	r := &Report{
+		From:       window[0].Timestamp,
+		To:         window[len(window)-1].Timestamp,
+		Events:     len(window),
+		PolicyHits: make(map[string]int),
+		Mood:       MoodStats{Moods: make(map[string]int)},
+	}
+
+	outputs, hits := 0, 0
+	for _, ev := range window {
+		switch ev.Type {
+		case "INPUT":
+			r.Turns++
+			for _, w := range storage.Tokenize(payloadString(ev, "text")) {
+				if !known[w] {
+					known[w] = true
+					r.NewVocabulary = append(r.NewVocabulary, w)
+				}
+			}
+			if s, ok := ev.Payload["mood_score"].(float64); ok {
+				r.Mood.Trajectory = append(r.Mood.Trajectory, s)
+			}
+			if m := payloadString(ev, "mood_now"); m != "" {
+				r.Mood.Moods[m]++
+			}
+		case "PROPOSE":
+			r.RulesCreated = append(r.RulesCreated, payloadString(ev, "word"))
+		case "EDIT":
+			r.RulesEdited = append(r.RulesEdited, payloadString(ev, "word"))
+		case "RETIRE":
+			r.RulesRetired = append(r.RulesRetired, payloadString(ev, "word"))
+		case "OUTPUT":
+			outputs++
+			if rules, ok := ev.Payload["rules"].([]any); ok && len(rules) > 0 {
+				hits++
+				for _, w := range rules {
+					r.PolicyHits[fmt.Sprint(w)]++
+				}
+			}
+		}
+	}
+	if outputs > 0 {
+		r.PolicyHitRate = float64(hits) / float64(outputs)
+	}
+
+	if t := r.Mood.Trajectory; len(t) > 0 {
+		r.Mood.Start, r.Mood.End = t[0], t[len(t)-1]
+		r.Mood.Min, r.Mood.Max = t[0], t[0]
+		sum := 0.0
+		for _, s := range t {
+			r.Mood.Min = min(r.Mood.Min, s)
+			r.Mood.Max = max(r.Mood.Max, s)
+			sum += s
+		}
+		r.Mood.Mean = sum / float64(len(t))
+	}
+	return r
+}
+
+// summarize renders a short human-readable account of a report.
+func summarize(r *Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reflection (%s) over %d turns, %s to %s.\n", r.Reason, r.Turns,
+		time.Unix(r.From, 0).UTC().Format(time.RFC3339), time.Unix(r.To, 0).UTC().Format(time.RFC3339))
+
+	if len(r.Mood.Trajectory) > 0 {
+		trend := "held steady"
+		switch {
+		case r.Mood.End-r.Mood.Start > 0.25:
+			trend = "improved"
+		case r.Mood.End-r.Mood.Start < -0.25:
+			trend = "declined"
+		}
+		fmt.Fprintf(&b, "My mood %s, from %.2f to %.2f (range %.2f..%.2f, mean %.2f).\n",
+			trend, r.Mood.Start, r.Mood.End, r.Mood.Min, r.Mood.Max, r.Mood.Mean)
+	}
+
+	fmt.Fprintf(&b, "I learned %d new words", len(r.NewVocabulary))
+	if n := len(r.NewVocabulary); n > 0 {
+		fmt.Fprintf(&b, ": %s", strings.Join(r.NewVocabulary[:min(n, 10)], ", "))
+		if n > 10 {
+			b.WriteString(", ...")
+		}
+	}
+	b.WriteString(".\n")
+
+	fmt.Fprintf(&b, "I created %d rules, edited %d and retired %d.\n",
+		len(r.RulesCreated), len(r.RulesEdited), len(r.RulesRetired))
+
+	fmt.Fprintf(&b, "My rules answered for me %.0f%% of the time", r.PolicyHitRate*100)
+	if len(r.PolicyHits) > 0 {
+		words := make([]string, 0, len(r.PolicyHits))
+		for w := range r.PolicyHits {
+			words = append(words, w)
+		}
+		sort.Slice(words, func(i, k int) bool {
+			if r.PolicyHits[words[i]] != r.PolicyHits[words[k]] {
+				return r.PolicyHits[words[i]] > r.PolicyHits[words[k]]
+			}
+			return words[i] < words[k]
+		})
+		fmt.Fprintf(&b, ", most often for '%s'", words[0])
+	}
+	b.WriteString(".")
+	return b.String()
+}
+
+func payloadString(ev structs.Event, key string) string {
+	s, _ := ev.Payload[key].(string)
+	return s
+}
@@ -1,3 +1,226 @@
 package selfmod
 
-// Proposal struct + validator.
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"neon/internal/clock"
+	"neon/internal/persona"
+	"neon/internal/policy"
+	"neon/internal/storage"
+)
+
+// Kind names the part of agent state a proposal changes.
+type Kind string
+
+const (
+	KindRuleAdd       Kind = "rule_add"
+	KindRuleEdit      Kind = "rule_edit"
+	KindRuleDelete    Kind = "rule_delete"
+	KindMoodThreshold Kind = "mood_threshold"
+	KindWeightPrune   Kind = "weight_prune"
+)
+
+// Status tracks a proposal through review.
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusApplied    Status = "applied"
+	StatusRejected   Status = "rejected"
+	StatusRolledBack Status = "rolled_back"
+)
+
+// Proposal describes a single change to agent state awaiting approval.
+// Which fields matter depends on Kind:
+//
+//	rule_add        Rule (new rule; its When.Word must not already have one)
+//	rule_edit       Word (existing rule), Rule.Then (new response)
+//	rule_delete     Word (existing rule)
+//	mood_threshold  Threshold (new |score| at which mood leaves neutral)
+//	weight_prune    Word (single word) or MinCount (drop words seen fewer times)
+type Proposal struct {
+	ID        string       `json:"id"`
+	Created   time.Time    `json:"created"`
+	Author    string       `json:"author"`
+	Reason    string       `json:"reason"`
+	Kind      Kind         `json:"kind"`
+	Rule      *policy.Rule `json:"rule,omitempty"`
+	Word      string       `json:"word,omitempty"`
+	Threshold float64      `json:"threshold,omitempty"`
+	MinCount  int          `json:"min_count,omitempty"`
+
+	Status   Status    `json:"status"`
+	Decided  time.Time `json:"decided,omitempty"`
+	Snapshot string    `json:"snapshot,omitempty"` // pre-apply snapshot for rollback
+	Note     string    `json:"note,omitempty"`     // reviewer's note or rejection reason
+}
+
+// Target is the agent state a proposal is validated and applied against.
+type Target interface {
+	Clock() clock.Clock
+	Policy() *policy.Engine
+	Weights() *storage.Weights
+	Mood() *persona.Engine
+	Snapshot(notes string) (string, error)
+	Restore(s *storage.Snapshot) error
+	Save() error
+}
+
+var validMoods = map[string]bool{
+	"":                           true,
+	string(persona.MoodNeutral):  true,
+	string(persona.MoodPositive): true,
+	string(persona.MoodNegative): true,
+}
+
+// Validate checks p's invariants against the current state of t.
+func Validate(p *Proposal, t Target) error {
+	if strings.TrimSpace(p.Author) == "" {
+		return errors.New("proposal needs an author")
+	}
+
+	switch p.Kind {
+	case KindRuleAdd:
+		if p.Rule == nil {
+			return errors.New("rule_add needs a rule")
+		}
+		if err := validateTrigger(p.Rule.When.Word); err != nil {
+			return err
+		}
+		if !validMoods[strings.ToLower(p.Rule.When.Mood)] {
+			return fmt.Errorf("unknown mood %q", p.Rule.When.Mood)
+		}
+		if strings.TrimSpace(p.Rule.Then) == "" {
+			return errors.New("rule needs a response")
+		}
+		if err := p.Rule.Validate(); err != nil {
+			return err
+		}
+		if err := t.Policy().CheckTrigger(p.Rule.When.Word); err != nil {
+			return err
+		}
+		if t.Policy().HasRuleFor(p.Rule.When.Word) {
+			return fmt.Errorf("a rule for %q already exists", p.Rule.When.Word)
+		}
+
+	case KindRuleEdit:
+		if p.Rule == nil || strings.TrimSpace(p.Rule.Then) == "" {
+			return errors.New("rule_edit needs a new response")
+		}
+		if err := policy.ValidateThen(p.Rule.Then); err != nil {
+			return err
+		}
+		if !t.Policy().HasRuleFor(p.Word) {
+			return fmt.Errorf("no rule for %q", p.Word)
+		}
+
+	case KindRuleDelete:
+		if !t.Policy().HasRuleFor(p.Word) {
+			return fmt.Errorf("no rule for %q", p.Word)
+		}
+
+	case KindMoodThreshold:
+		// Keep the threshold inside the score clamp so positive and
+		// negative moods stay reachable.
+		if limit := t.Mood().Clamp(); p.Threshold <= 0 || p.Threshold >= limit {
+			return fmt.Errorf("threshold must be in (0, %g), got %g", limit, p.Threshold)
+		}
+
+	case KindWeightPrune:
+		counts := t.Weights().Snapshot()
+		if p.Word != "" {
+			if _, ok := counts[p.Word]; !ok {
+				return fmt.Errorf("no weight for %q", p.Word)
+			}
+			break
+		}
+		if p.MinCount < 2 {
+			return errors.New("weight_prune needs a word or a min_count of at least 2")
+		}
+		kept := 0
+		for _, c := range counts {
+			if c >= p.MinCount {
+				kept++
+			}
+		}
+		if kept == 0 {
+			return fmt.Errorf("min_count %d would forget every word", p.MinCount)
+		}
+
+	default:
+		return fmt.Errorf("unknown proposal kind %q", p.Kind)
+	}
+	return nil
+}
+
+// validateTrigger requires a rule trigger to be exactly one token.
+func validateTrigger(word string) error {
+	toks := storage.Tokenize(word)
+	if len(toks) != 1 || toks[0] != strings.ToLower(strings.TrimSpace(word)) {
+		return fmt.Errorf("rule trigger %q must be a single word", word)
+	}
+	return nil
+}
+
+// Apply validates p, snapshots t and then makes the change, persisting it.
+// The snapshot path is recorded on p so the change can be rolled back.
+func Apply(p *Proposal, t Target) error {
+	if p.Status != StatusPending {
+		return fmt.Errorf("proposal %s is %s, not pending", p.ID, p.Status)
+	}
+	if err := Validate(p, t); err != nil {
+		return err
+	}
+
+	snap, err := t.Snapshot(fmt.Sprintf("before proposal %s (%s)", p.ID, p.Kind))
+	if err != nil {
+		return fmt.Errorf("snapshot before apply: %w", err)
+	}
+	p.Snapshot = snap
+
+	switch p.Kind {
+	case KindRuleAdd:
+		r := *p.Rule
+		if r.Author == "" {
+			r.Author = p.Author
+		}
+		if err := t.Policy().AddRule(r); err != nil {
+			return err
+		}
+	case KindRuleEdit:
+		t.Policy().UpdateRule(p.Word, p.Rule.Then)
+	case KindRuleDelete:
+		t.Policy().DeleteRule(p.Word)
+	case KindMoodThreshold:
+		t.Mood().SetThreshold(p.Threshold)
+	case KindWeightPrune:
+		t.Weights().Prune(p.Word, p.MinCount)
+	}
+	if err := t.Save(); err != nil {
+		return err
+	}
+
+	p.Status = StatusApplied
+	p.Decided = t.Clock().Now().UTC()
+	return nil
+}
+
+// Rollback restores the snapshot taken when p was applied.
+func Rollback(p *Proposal, t Target) error {
+	if p.Status != StatusApplied || p.Snapshot == "" {
+		return fmt.Errorf("proposal %s was not applied", p.ID)
+	}
+	snap, err := storage.LoadSnapshot(p.Snapshot)
+	if err != nil {
+		return err
+	}
+	if err := t.Restore(snap); err != nil {
+		return err
+	}
+	p.Status = StatusRolledBack
+	p.Decided = t.Clock().Now().UTC()
+	return nil
+}
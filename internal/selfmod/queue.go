@@ -0,0 +1,88 @@
+package selfmod
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"neon/internal/clock"
+	"neon/internal/storage"
+)
+
+// Queue stores proposals on disk, one JSON file each under
+// root/data/proposals. A proposal stays in the queue after it is decided;
+// its Status records the outcome.
+type Queue struct {
+	dir   string
+	clock clock.Clock
+}
+
+// NewQueue opens the proposal queue under root. Proposals are stamped
+// with clk.
+func NewQueue(root string, clk clock.Clock) *Queue {
+	return &Queue{dir: filepath.Join(root, "data", "proposals"), clock: clk}
+}
+
+// Submit assigns p an ID, marks it pending and writes it to the queue.
+func (q *Queue) Submit(p *Proposal) error {
+	now := q.clock.Now().UTC()
+	// IDs come from the creation time. A simulated clock can hand out the
+	// same time twice, so step past IDs already taken.
+	for n := now.UnixNano(); ; n++ {
+		p.ID = "p-" + strconv.FormatInt(n, 36)
+		if !storage.Exists(q.path(p.ID)) {
+			break
+		}
+	}
+	p.Created = now
+	p.Status = StatusPending
+	return q.Put(p)
+}
+
+// Put writes p (new or updated) atomically.
+func (q *Queue) Put(p *Proposal) error {
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return err
+	}
+	return storage.AtomicWriteJSON(q.path(p.ID), p)
+}
+
+// Get loads a proposal by ID.
+func (q *Queue) Get(id string) (*Proposal, error) {
+	var p Proposal
+	if err := storage.ReadJSON(q.path(id), &p); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no proposal %q", id)
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List returns proposals with the given status (all if status is ""),
+// oldest first.
+func (q *Queue) List(status Status) ([]*Proposal, error) {
+	files, err := filepath.Glob(filepath.Join(q.dir, "p-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var out []*Proposal
+	for _, f := range files {
+		var p Proposal
+		if err := storage.ReadJSON(f, &p); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		if status == "" || p.Status == status {
+			out = append(out, &p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out, nil
+}
+
+func (q *Queue) path(id string) string {
+	// IDs come from the command line; keep them inside the queue dir.
+	return filepath.Join(q.dir, filepath.Base(id)+".json")
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Migration upgrades a raw snapshot document by exactly one schema version.
+// It edits doc in place; the schema field is bumped by MigrateSnapshot.
+type Migration func(doc map[string]json.RawMessage) error
+
+// migrations maps a source schema version to the step that upgrades it.
+var migrations = map[int]Migration{}
+
+// RegisterMigration adds the step upgrading schema from to from+1.
+// Registering the same step twice is a programming error.
+func RegisterMigration(from int, m Migration) {
+	if _, dup := migrations[from]; dup {
+		panic(fmt.Sprintf("storage: duplicate snapshot migration from v%d", from))
+	}
+	migrations[from] = m
+}
+
+func init() {
+	RegisterMigration(1, migrateV1toV2)
+}
+
+// MigrateSnapshot upgrades a snapshot document to the current schema by
+// applying each registered step in turn. It returns the (possibly
+// unchanged) document and the schema it started at.
+func MigrateSnapshot(data []byte) ([]byte, int, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	var from int
+	if raw, ok := doc["schema"]; ok {
+		if err := json.Unmarshal(raw, &from); err != nil {
+			return nil, 0, fmt.Errorf("snapshot schema: %w", err)
+		}
+	}
+	if from < 1 {
+		return nil, from, fmt.Errorf("snapshot has no schema version")
+	}
+	if from > SnapshotSchema {
+		return nil, from, fmt.Errorf("snapshot schema %d is newer than supported %d", from, SnapshotSchema)
+	}
+	if from == SnapshotSchema {
+		return data, from, nil
+	}
+
+	for v := from; v < SnapshotSchema; v++ {
+		step, ok := migrations[v]
+		if !ok {
+			return nil, from, fmt.Errorf("no snapshot migration from v%d", v)
+		}
+		if err := step(doc); err != nil {
+			return nil, from, fmt.Errorf("migrate v%d to v%d: %w", v, v+1, err)
+		}
+		doc["schema"] = json.RawMessage(fmt.Sprint(v + 1))
+	}
+
+	out, err := json.Marshal(doc)
+	return out, from, err
+}
+
+// MigrateSnapshotFile rewrites the snapshot at path in the current schema.
+// It returns the schema the file was at; files already current are left
+// untouched.
+func MigrateSnapshotFile(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	out, from, err := MigrateSnapshot(b)
+	if err != nil || from == SnapshotSchema {
+		return from, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(out, &s); err != nil {
+		return from, err
+	}
+	return from, AtomicWriteJSON(path, &s)
+}
+
+// migrateV1toV2: word weights moved from "weights" (floats) to "counts"
+// (integers), matching what Weights actually stores. The optional rules,
+// mood and seen fields were already written under v1 and need no change.
+func migrateV1toV2(doc map[string]json.RawMessage) error {
+	raw, ok := doc["weights"]
+	delete(doc, "weights")
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	var weights map[string]float64
+	if err := json.Unmarshal(raw, &weights); err != nil {
+		return fmt.Errorf("weights: %w", err)
+	}
+	counts := make(map[string]int, len(weights))
+	for k, v := range weights {
+		counts[k] = int(math.Round(v))
+	}
+	b, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	doc["counts"] = b
+	return nil
+}
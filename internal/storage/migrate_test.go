@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// v1Fixture is the oldest snapshot in the repository, written before
+// schemas were migrated.
+var v1Fixture = filepath.Join("..", "..", "data", "snapshots", "run-2025-09-24T11-15-01Z.json")
+
+func TestMigrateV1Fixture(t *testing.T) {
+	data, err := os.ReadFile(v1Fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, from, err := MigrateSnapshot(data)
+	if err != nil {
+		t.Fatalf("MigrateSnapshot: %v", err)
+	}
+	if from != 1 {
+		t.Errorf("from = %d, want 1", from)
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc["weights"]; ok {
+		t.Error("migrated snapshot still has weights")
+	}
+	var s Snapshot
+	if err := json.Unmarshal(out, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Schema != SnapshotSchema {
+		t.Errorf("schema = %d, want %d", s.Schema, SnapshotSchema)
+	}
+	if s.Notes != "first test snapshot" || s.Self["id"] != "NEON" {
+		t.Errorf("fields lost in migration: %+v", s)
+	}
+}
+
+func TestMigrateV1Weights(t *testing.T) {
+	in := `{"schema":1,"weights":{"hello":2.6,"world":1},"notes":"n"}`
+	out, _, err := MigrateSnapshot([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(out, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Counts["hello"] != 3 || s.Counts["world"] != 1 || len(s.Counts) != 2 {
+		t.Errorf("counts = %v, want hello:3 world:1", s.Counts)
+	}
+}
+
+func TestMigrateCurrentUnchanged(t *testing.T) {
+	in := []byte(`{"schema":` + strconv.Itoa(SnapshotSchema) + `,"counts":{"a":1}}`)
+	out, from, err := MigrateSnapshot(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != SnapshotSchema {
+		t.Errorf("from = %d, want %d", from, SnapshotSchema)
+	}
+	if string(out) != string(in) {
+		t.Errorf("current snapshot was rewritten: %s", out)
+	}
+}
+
+func TestMigrateRejectsUnknownSchema(t *testing.T) {
+	for _, in := range []string{
+		`{"schema":` + strconv.Itoa(SnapshotSchema+1) + `}`,
+		`{"notes":"no schema"}`,
+		`{"schema":"two"}`,
+	} {
+		if _, _, err := MigrateSnapshot([]byte(in)); err == nil {
+			t.Errorf("MigrateSnapshot(%s) succeeded, want error", in)
+		}
+	}
+}
+
+func TestLoadAndMigrateFile(t *testing.T) {
+	data, err := os.ReadFile(v1Fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "old.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.Schema != SnapshotSchema {
+		t.Errorf("loaded schema = %d, want %d", s.Schema, SnapshotSchema)
+	}
+
+	from, err := MigrateSnapshotFile(path)
+	if err != nil || from != 1 {
+		t.Fatalf("MigrateSnapshotFile = %d, %v; want 1, nil", from, err)
+	}
+	b, _ := os.ReadFile(path)
+	if !strings.Contains(string(b), `"schema": `+strconv.Itoa(SnapshotSchema)) {
+		t.Errorf("file not rewritten in the current schema:\n%s", b)
+	}
+	if from, err := MigrateSnapshotFile(path); err != nil || from != SnapshotSchema {
+		t.Errorf("second MigrateSnapshotFile = %d, %v; want %d, nil", from, err, SnapshotSchema)
+	}
+}
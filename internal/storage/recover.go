@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RecoverTemps finds *.tmp files left under dir by AtomicWriteJSON when the
+// process died mid-write. A temp file whose target is missing and which
+// holds valid JSON was fully synced before the crash, so it is promoted;
+// anything else is removed. It returns a description of each action.
+func RecoverTemps(dir string) ([]string, error) {
+	var actions []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		target := strings.TrimSuffix(path, ".tmp")
+		if !Exists(target) && validJSON(path) {
+			if err := os.Rename(path, target); err != nil {
+				return err
+			}
+			actions = append(actions, "promoted "+path)
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		actions = append(actions, "removed stale "+path)
+		return nil
+	})
+	return actions, err
+}
+
+// Quarantine moves a damaged file aside (path.corrupt, .corrupt-1, ...) so
+// it can be inspected later without being loaded again.
+func Quarantine(path string) (string, error) {
+	dst := path + ".corrupt"
+	for i := 1; Exists(dst); i++ {
+		dst = path + ".corrupt-" + strconv.Itoa(i)
+	}
+	return dst, os.Rename(path, dst)
+}
+
+func validJSON(path string) bool {
+	b, err := os.ReadFile(path)
+	return err == nil && json.Valid(b)
+}
@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SnapshotInfo summarizes one snapshot file for listing.
+type SnapshotInfo struct {
+	Path      string    `json:"path"`
+	Timestamp time.Time `json:"timestamp"`
+	Notes     string    `json:"notes"`
+	Size      int64     `json:"size"`
+	Words     int       `json:"words"`
+	Rules     int       `json:"rules"`
+	Err       error     `json:"-"` // set if the file could not be loaded
+}
+
+// SnapshotsDir returns the directory snapshots are written to under root.
+func SnapshotsDir(root string) string {
+	return filepath.Join(root, "data", "snapshots")
+}
+
+// ListSnapshots returns every snapshot under root, oldest first.
+// Unreadable files are included with Err set so callers can report them.
+func ListSnapshots(root string) ([]SnapshotInfo, error) {
+	files, err := filepath.Glob(filepath.Join(SnapshotsDir(root), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SnapshotInfo, 0, len(files))
+	for _, f := range files {
+		info := SnapshotInfo{Path: f}
+		if st, err := os.Stat(f); err == nil {
+			info.Size = st.Size()
+			info.Timestamp = st.ModTime().UTC()
+		}
+		s, err := LoadSnapshot(f)
+		if err != nil {
+			info.Err = err
+			out = append(out, info)
+			continue
+		}
+		info.Timestamp = s.Timestamp
+		info.Notes = s.Notes
+		info.Words = len(s.Counts)
+		info.Rules = len(ruleList(s.Rules))
+		out = append(out, info)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out, nil
+}
+
+// SnapshotDiff describes how snapshot B differs from snapshot A.
+type SnapshotDiff struct {
+	WordsAdded   []string             `json:"words_added"`
+	WordsRemoved []string             `json:"words_removed"`
+	WordsChanged map[string][2]int    `json:"words_changed"` // word → [count in A, count in B]
+	RulesAdded   []string             `json:"rules_added"`   // canonical rule JSON
+	RulesRemoved []string             `json:"rules_removed"`
+	RulesChanged [][2]string          `json:"rules_changed"` // [rule in A, rule in B], same conditions
+	MoodChanged  map[string][2]any    `json:"mood_changed"`  // field → [value in A, value in B]
+	SeenAdded    []string             `json:"seen_added"`
+	Meta         map[string][2]string `json:"meta"`
+}
+
+// DiffSnapshots compares two snapshots. Rules are matched by their "when"
+// conditions, so a rule whose response changed is reported as changed
+// rather than as one removal and one addition. Rules sharing a condition
+// are matched in order: the first in a with the first in b, and so on.
+func DiffSnapshots(a, b *Snapshot) SnapshotDiff {
+	d := SnapshotDiff{
+		WordsChanged: make(map[string][2]int),
+		MoodChanged:  make(map[string][2]any),
+		Meta: map[string][2]string{
+			"timestamp": {a.Timestamp.Format(time.RFC3339), b.Timestamp.Format(time.RFC3339)},
+			"notes":     {a.Notes, b.Notes},
+		},
+	}
+
+	for w, cb := range b.Counts {
+		ca, ok := a.Counts[w]
+		switch {
+		case !ok:
+			d.WordsAdded = append(d.WordsAdded, w)
+		case ca != cb:
+			d.WordsChanged[w] = [2]int{ca, cb}
+		}
+	}
+	for w := range a.Counts {
+		if _, ok := b.Counts[w]; !ok {
+			d.WordsRemoved = append(d.WordsRemoved, w)
+		}
+	}
+	sort.Strings(d.WordsAdded)
+	sort.Strings(d.WordsRemoved)
+
+	ra, rb := keyedRules(a.Rules), keyedRules(b.Rules)
+	for k, rule := range rb {
+		old, ok := ra[k]
+		switch {
+		case !ok:
+			d.RulesAdded = append(d.RulesAdded, rule)
+		case old != rule:
+			d.RulesChanged = append(d.RulesChanged, [2]string{old, rule})
+		}
+	}
+	for k, rule := range ra {
+		if _, ok := rb[k]; !ok {
+			d.RulesRemoved = append(d.RulesRemoved, rule)
+		}
+	}
+	sort.Strings(d.RulesAdded)
+	sort.Strings(d.RulesRemoved)
+	sort.Slice(d.RulesChanged, func(i, j int) bool { return d.RulesChanged[i][0] < d.RulesChanged[j][0] })
+
+	ma, mb := fields(a.Mood), fields(b.Mood)
+	for k, vb := range mb {
+		if va, ok := ma[k]; !ok || !jsonEqual(va, vb) {
+			d.MoodChanged[k] = [2]any{ma[k], vb}
+		}
+	}
+	for k, va := range ma {
+		if _, ok := mb[k]; !ok {
+			d.MoodChanged[k] = [2]any{va, nil}
+		}
+	}
+
+	seen := make(map[string]bool, len(a.Seen))
+	for _, w := range a.Seen {
+		seen[w] = true
+	}
+	for _, w := range b.Seen {
+		if !seen[w] {
+			d.SeenAdded = append(d.SeenAdded, w)
+		}
+	}
+	return d
+}
+
+// RetentionPolicy decides which snapshots PruneSnapshots keeps. A snapshot
+// is kept if any rule keeps it.
+type RetentionPolicy struct {
+	KeepLast  int // keep the newest N snapshots
+	KeepDaily int // keep the newest snapshot of each of the last N days
+}
+
+// PruneSnapshots deletes snapshots under root that policy doesn't keep,
+// never touching paths in protect. With dryRun set nothing is deleted.
+// It returns the paths removed (or that would be removed).
+func PruneSnapshots(root string, policy RetentionPolicy, protect map[string]bool, now time.Time, dryRun bool) ([]string, error) {
+	infos, err := ListSnapshots(root)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool)
+	for i := len(infos) - 1; i >= 0 && len(infos)-i <= policy.KeepLast; i-- {
+		keep[infos[i].Path] = true
+	}
+	if policy.KeepDaily > 0 {
+		cutoff := now.UTC().AddDate(0, 0, -policy.KeepDaily)
+		days := make(map[string]bool)
+		for i := len(infos) - 1; i >= 0; i-- {
+			ts := infos[i].Timestamp.UTC()
+			day := ts.Format("2006-01-02")
+			if ts.After(cutoff) && !days[day] {
+				days[day] = true
+				keep[infos[i].Path] = true
+			}
+		}
+	}
+
+	var removed []string
+	for _, info := range infos {
+		// Unreadable snapshots are left for a human to look at.
+		if info.Err != nil || keep[info.Path] || protect[filepath.Clean(info.Path)] {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(info.Path); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, info.Path)
+	}
+	return removed, nil
+}
+
+// ruleList splits a raw rules array into its elements.
+func ruleList(raw json.RawMessage) []json.RawMessage {
+	var rules []json.RawMessage
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &rules)
+	}
+	return rules
+}
+
+// keyedRules maps each rule's canonical "when" conditions to the rule's
+// canonical JSON. Rule stats are left out so hits don't count as changes.
+// Rules sharing a condition are told apart by a "#n" suffix on the key,
+// counting from 0 in list order.
+func keyedRules(raw json.RawMessage) map[string]string {
+	out := make(map[string]string)
+	dup := make(map[string]int)
+	for _, r := range ruleList(raw) {
+		var fields map[string]json.RawMessage
+		key, rule := canonical(r), canonical(r)
+		if err := json.Unmarshal(r, &fields); err == nil {
+			if when, ok := fields["when"]; ok {
+				key = canonical(when)
+			}
+			delete(fields, "stats")
+			b, _ := json.Marshal(fields)
+			rule = canonical(b)
+		}
+		n := dup[key]
+		dup[key]++
+		out[fmt.Sprintf("%s#%d", key, n)] = rule
+	}
+	return out
+}
+
+// canonical re-encodes JSON so equal values compare equal as strings.
+func canonical(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func fields(raw json.RawMessage) map[string]any {
+	m := make(map[string]any)
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &m)
+	}
+	return m
+}
+
+func jsonEqual(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
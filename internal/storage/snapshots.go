@@ -1,50 +1,71 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 // Snapshot captures NEON's state for rollback.
+// Older on-disk schemas are upgraded on load; see migrate.go.
 type Snapshot struct {
-	Schema    int                `json:"schema"`
-	Timestamp time.Time          `json:"timestamp"`
-	Self      map[string]any     `json:"self"`
-	Beliefs   []string           `json:"beliefs"`
-	Weights   map[string]float64 `json:"weights"`
-	Features  map[string]bool    `json:"features"`
-	Notes     string             `json:"notes"`
+	Schema    int             `json:"schema"`
+	Timestamp time.Time       `json:"timestamp"`
+	Self      map[string]any  `json:"self"`
+	Beliefs   []string        `json:"beliefs"`
+	Counts    map[string]int  `json:"counts"` // word weights, as in Weights
+	Features  map[string]bool `json:"features"`
+	Rules     json.RawMessage `json:"rules,omitempty"` // policy rules as saved by policy.Engine
+	Mood      json.RawMessage `json:"mood,omitempty"`  // persona mood state
+	Seen      []string        `json:"seen,omitempty"`  // words cognition has seen
+	Notes     string          `json:"notes"`
 }
 
-const snapshotSchema = 1
+// SnapshotSchema is the version written by WriteSnapshot.
+const SnapshotSchema = 2
 
 func snapshotPath(root string, t time.Time) string {
 	name := "run-" + t.UTC().Format("2006-01-02T15-04-05Z") + ".json"
-	return filepath.Join(root, "data", "snapshots", name)
+	return filepath.Join(SnapshotsDir(root), name)
 }
 
-func SaveSnapshot(root string, self map[string]any, beliefs []string, weights map[string]float64, features map[string]bool, notes string) (string, error) {
-	s := Snapshot{
-		Schema:    snapshotSchema,
-		Timestamp: time.Now().UTC(),
-		Self:      self,
-		Beliefs:   beliefs,
-		Weights:   weights,
-		Features:  features,
-		Notes:     notes,
+// WriteSnapshot stamps s with the current schema and writes it under
+// root/data/snapshots, returning the path. s must carry its timestamp,
+// which names the file.
+func WriteSnapshot(root string, s Snapshot) (string, error) {
+	if s.Timestamp.IsZero() {
+		return "", fmt.Errorf("snapshot has no timestamp")
+	}
+	s.Schema = SnapshotSchema
+	if err := os.MkdirAll(SnapshotsDir(root), 0o755); err != nil {
+		return "", err
 	}
 	p := snapshotPath(root, s.Timestamp)
+	// Names have one-second resolution; don't clobber an earlier snapshot
+	// taken in the same second.
+	for i := 1; Exists(p); i++ {
+		p = strings.TrimSuffix(snapshotPath(root, s.Timestamp), ".json") + fmt.Sprintf("-%d.json", i)
+	}
 	return p, AtomicWriteJSON(p, &s)
 }
 
+// LoadSnapshot reads a snapshot, upgrading it in memory if it was written
+// with an older schema.
 func LoadSnapshot(path string) (*Snapshot, error) {
-	var s Snapshot
-	if err := ReadJSON(path, &s); err != nil {
+	b, err := os.ReadFile(path)
+	if err != nil {
 		return nil, err
 	}
-	if s.Schema != snapshotSchema {
-		return nil, fmt.Errorf("snapshot schema mismatch: got %d", s.Schema)
+	b, _, err = MigrateSnapshot(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
 	}
 	return &s, nil
 }
@@ -31,7 +31,7 @@ func (w *Weights) Update(text string) {
 	w.mu.Unlock()
 }
 
-// TopN returns the top-N words sorted by frequency.
+// TopN returns the top-N words sorted by frequency, ties alphabetically.
 func (w *Weights) TopN(n int) []WordCount {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
@@ -42,7 +42,10 @@ func (w *Weights) TopN(n int) []WordCount {
 	}
 
 	sort.Slice(pairs, func(i, j int) bool {
-		return pairs[i].Count > pairs[j].Count
+		if pairs[i].Count != pairs[j].Count {
+			return pairs[i].Count > pairs[j].Count
+		}
+		return pairs[i].Word < pairs[j].Word
 	})
 
 	if n > 0 && n < len(pairs) {
@@ -51,6 +54,13 @@ func (w *Weights) TopN(n int) []WordCount {
 	return pairs
 }
 
+// Count returns how many times word has been seen.
+func (w *Weights) Count(word string) int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.count[word]
+}
+
 // Snapshot returns a copy of the current word counts.
 func (w *Weights) Snapshot() map[string]int {
 	w.mu.RLock()
@@ -63,6 +73,36 @@ func (w *Weights) Snapshot() map[string]int {
 	return copyMap
 }
 
+// Replace swaps in a new set of word counts.
+func (w *Weights) Replace(counts map[string]int) {
+	m := make(map[string]int, len(counts))
+	for k, v := range counts {
+		m[k] = v
+	}
+	w.mu.Lock()
+	w.count = m
+	w.dirty = true
+	w.mu.Unlock()
+}
+
+// Prune removes words seen fewer than minCount times, or just word if it
+// is non-empty. It returns the number of words removed.
+func (w *Weights) Prune(word string, minCount int) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	removed := 0
+	for k, v := range w.count {
+		if (word != "" && k == word) || (word == "" && v < minCount) {
+			delete(w.count, k)
+			removed++
+		}
+	}
+	if removed > 0 {
+		w.dirty = true
+	}
+	return removed
+}
+
 // Save persists the weights using AtomicWriteJSON if dirty.
 func (w *Weights) Save(path string) error {
 	w.mu.Lock()
@@ -101,13 +141,15 @@ type WordCount struct {
 	Count int    `json:"count"`
 }
 
-// Tokenize splits text into lowercase tokens.
+// Tokenize splits text into lowercase tokens. Curly quotes split words
+// like straight ones, so "don’t" gives the same tokens as "don't".
 func Tokenize(s string) []string {
 	s = strings.ToLower(s)
 	repl := strings.NewReplacer(
 		".", " ", ",", " ", "!", " ", "?", " ",
 		"(", " ", ")", " ", "[", " ", "]", " ",
 		"\"", " ", "'", " ", ":", " ", ";", " ",
+		"‘", " ", "’", " ", "“", " ", "”", " ",
 	)
 	s = repl.Replace(s)
 	return strings.Fields(s)
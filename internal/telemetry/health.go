@@ -2,18 +2,20 @@ package telemetry
 
 import (
 	"sync/atomic"
-	"time"
+
+	"neon/internal/clock"
 )
 
 // Health holds simple runtime counters
 type Health struct {
+	clock     clock.Clock
 	startedAt int64
 	events    int64
 	errors    int64
 }
 
-func NewHealth() *Health {
-	return &Health{startedAt: time.Now().Unix()}
+func NewHealth(clk clock.Clock) *Health {
+	return &Health{clock: clk, startedAt: clk.Now().Unix()}
 }
 
 func (h *Health) IncEvents() {
@@ -26,7 +28,7 @@ func (h *Health) IncErrors() {
 
 func (h *Health) Snapshot() map[string]any {
 	return map[string]any{
-		"uptime_sec": time.Now().Unix() - h.startedAt,
+		"uptime_sec": h.clock.Now().Unix() - h.startedAt,
 		"events":     atomic.LoadInt64(&h.events),
 		"errors":     atomic.LoadInt64(&h.errors),
 	}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"neon/internal/clock"
 	"neon/pkg/structs"
 )
 
@@ -19,29 +20,61 @@ type Logger struct {
 	day    string
 	root   string
 	events chan structs.Event
+	flush  chan chan struct{}
 	stop   chan struct{}
+	done   chan struct{}
 	health *Health
+	every  time.Duration // between HEALTH events
+	clock  clock.Clock
 }
 
-// NewLogger creates and starts a logger goroutine
-func NewLogger(root string) *Logger {
+// DefaultBuffer is how many events a logger queues by default.
+const DefaultBuffer = 100
+
+// Options tune a logger. Zero values take the defaults, except that a
+// zero HealthInterval disables HEALTH events.
+type Options struct {
+	Buffer         int           // events queued before new ones are dropped
+	HealthInterval time.Duration // between HEALTH events
+}
+
+// NewLogger creates and starts a logger goroutine tuned by opts. Events
+// are stamped, and log files named, using clk; it is also the clock the
+// rest of the agent runs on.
+func NewLogger(root string, opts Options, clk clock.Clock) *Logger {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultBuffer
+	}
 	l := &Logger{
 		root:   root,
-		events: make(chan structs.Event, 100),
+		events: make(chan structs.Event, opts.Buffer),
+		flush:  make(chan chan struct{}),
 		stop:   make(chan struct{}),
-		health: NewHealth(),
+		done:   make(chan struct{}),
+		health: NewHealth(clk),
+		every:  opts.HealthInterval,
+		clock:  clk,
 	}
 	go l.loop()
-	go l.periodicHealth() // emit HEALTH snapshots every 30s
+	go l.periodicHealth() // emit HEALTH snapshots every opts.HealthInterval
 	return l
 }
 
 func (l *Logger) loop() {
+	defer close(l.done)
 	for {
 		select {
 		case ev := <-l.events:
 			_ = l.write(ev)
+		case ack := <-l.flush:
+			l.drain()
+			if l.file != nil {
+				_ = l.file.Sync()
+			}
+			close(ack)
 		case <-l.stop:
+			// Drain whatever is still queued so Close doesn't lose events.
+			l.drain()
 			if l.file != nil {
 				_ = l.file.Close()
 			}
@@ -50,13 +83,25 @@ func (l *Logger) loop() {
 	}
 }
 
+// drain writes every event currently queued.
+func (l *Logger) drain() {
+	for {
+		select {
+		case ev := <-l.events:
+			_ = l.write(ev)
+		default:
+			return
+		}
+	}
+}
+
 func (l *Logger) write(ev structs.Event) error {
-	day := time.Now().Format("2006-01-02")
+	day := l.clock.Now().Format("2006-01-02")
 	if l.day != day {
 		if l.file != nil {
 			_ = l.file.Close()
 		}
-		path := filepath.Join(l.root, "data", "events", fmt.Sprintf("events-%s.jsonl", day))
+		path := filepath.Join(EventsDir(l.root), fmt.Sprintf("events-%s.jsonl", day))
 		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 			return err
 		}
@@ -71,8 +116,9 @@ func (l *Logger) write(ev structs.Event) error {
 	return l.enc.Encode(ev)
 }
 
-// Log queues an event for writing
+// Log stamps an event with the logger's clock and queues it for writing
 func (l *Logger) Log(ev structs.Event) {
+	ev.Timestamp = l.clock.Now().Unix()
 	l.health.IncEvents()
 	select {
 	case l.events <- ev:
@@ -82,14 +128,22 @@ func (l *Logger) Log(ev structs.Event) {
 	}
 }
 
+// Clock returns the clock the logger stamps events with.
+func (l *Logger) Clock() clock.Clock {
+	return l.clock
+}
+
 // Health returns a snapshot of runtime counters
 func (l *Logger) Health() map[string]any {
 	return l.health.Snapshot()
 }
 
-// periodicHealth emits a HEALTH event every 30s
+// periodicHealth emits a HEALTH event every l.every
 func (l *Logger) periodicHealth() {
-	ticker := time.NewTicker(30 * time.Second)
+	if l.every <= 0 {
+		return
+	}
+	ticker := time.NewTicker(l.every)
 	defer ticker.Stop()
 	for {
 		select {
@@ -102,7 +156,19 @@ func (l *Logger) periodicHealth() {
 	}
 }
 
-// Close stops the logger goroutine
+// Flush blocks until every event queued so far is written and synced to
+// disk. It returns immediately if the logger is closed.
+func (l *Logger) Flush() {
+	ack := make(chan struct{})
+	select {
+	case l.flush <- ack:
+		<-ack
+	case <-l.done:
+	}
+}
+
+// Close stops the logger goroutine after flushing queued events
 func (l *Logger) Close() {
 	close(l.stop)
+	<-l.done
 }
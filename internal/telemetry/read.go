@@ -0,0 +1,173 @@
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"neon/pkg/structs"
+)
+
+// EventsDir returns the directory the logger writes under root.
+func EventsDir(root string) string {
+	return filepath.Join(root, "data", "events")
+}
+
+// ReadEvents reads every events-*.jsonl file in dir and returns the events
+// with a timestamp after since (unix seconds; 0 for all), sorted by
+// timestamp. Lines that fail to decode (e.g. torn writes) are skipped.
+func ReadEvents(dir string, since int64) ([]structs.Event, error) {
+	logged, err := readLog(dir, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var events []structs.Event
+	for _, l := range logged {
+		if l.ev.Timestamp > since {
+			events = append(events, l.ev)
+		}
+	}
+	return events, nil
+}
+
+// Cursor is a position in the event log: for each events file, by base
+// name, the byte offset just past the events read from it so far. Files
+// are only ever appended to, so events logged after a cursor was taken
+// are past it whatever their timestamps.
+type Cursor map[string]int64
+
+// ReadEventsAfter returns the events logged after from, sorted by
+// timestamp, and the cursor just past them. A nil cursor reads the whole
+// log. Only complete lines are read, so an event being written is picked
+// up by the next read.
+func ReadEventsAfter(dir string, from Cursor) ([]structs.Event, Cursor, error) {
+	logged, err := readLog(dir, from, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	next := make(Cursor, len(from))
+	for name, off := range from {
+		next[name] = off
+	}
+	events := make([]structs.Event, len(logged))
+	for i, l := range logged {
+		events[i] = l.ev
+		next[l.file] = max(next[l.file], l.end)
+	}
+	return events, next, nil
+}
+
+// ReadEventsUntil returns the events logged before to, sorted by timestamp.
+func ReadEventsUntil(dir string, to Cursor) ([]structs.Event, error) {
+	if len(to) == 0 {
+		return nil, nil
+	}
+	logged, err := readLog(dir, nil, to)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]structs.Event, len(logged))
+	for i, l := range logged {
+		events[i] = l.ev
+	}
+	return events, nil
+}
+
+// CursorAfter returns the cursor just past the events, taken in timestamp
+// order, for which covered returns true; it converts positions recorded
+// some other way, such as a count of events or a time.
+func CursorAfter(dir string, covered func(i int, ev structs.Event) bool) (Cursor, error) {
+	logged, err := readLog(dir, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := make(Cursor)
+	for i, l := range logged {
+		if covered(i, l.ev) {
+			c[l.file] = max(c[l.file], l.end)
+		}
+	}
+	return c, nil
+}
+
+// loggedEvent is an event with where it ends in the log.
+type loggedEvent struct {
+	ev   structs.Event
+	file string
+	end  int64
+}
+
+// readLog reads the events in every events file in dir between from and
+// to (nil for the start and end of each file; files missing from a
+// non-nil to are skipped), sorted by timestamp.
+func readLog(dir string, from, to Cursor) ([]loggedEvent, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var logged []loggedEvent
+	for _, path := range files {
+		name := filepath.Base(path)
+		limit := int64(-1)
+		if to != nil {
+			end, ok := to[name]
+			if !ok {
+				continue
+			}
+			limit = end
+		}
+		if logged, err = readFile(path, from[name], limit, logged); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	// Stable so that events sharing a second keep their logged order.
+	sort.SliceStable(logged, func(i, j int) bool {
+		return logged[i].ev.Timestamp < logged[j].ev.Timestamp
+	})
+	return logged, nil
+}
+
+// readFile appends the events of each complete line of path from offset
+// from up to limit (-1 for the end of the file). Lines that fail to decode
+// (e.g. torn writes) are skipped.
+func readFile(path string, from, limit int64, logged []loggedEvent) ([]loggedEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return logged, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(from, io.SeekStart); err != nil {
+		return logged, err
+	}
+	var r io.Reader = f
+	if limit >= 0 {
+		r = io.LimitReader(f, max(limit-from, 0))
+	}
+
+	name := filepath.Base(path)
+	br := bufio.NewReaderSize(r, 64*1024)
+	end := from
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return logged, nil // an incomplete last line is left for later
+		}
+		if err != nil {
+			return logged, err
+		}
+		end += int64(len(line))
+		var ev structs.Event
+		if json.Unmarshal(bytes.TrimSpace(line), &ev) != nil {
+			continue
+		}
+		logged = append(logged, loggedEvent{ev: ev, file: name, end: end})
+	}
+}
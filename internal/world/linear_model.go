@@ -1,3 +1,314 @@
 package world
 
-// Linear predictive model.
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"neon/internal/storage"
+)
+
+// Prediction is what the model expects the next turn to look like.
+type Prediction struct {
+	MoodDelta float64  `json:"mood_delta"` // expected change in mood score
+	Topics    []string `json:"topics"`     // words expected in the next input
+}
+
+// Surprise compares a prediction against what actually happened.
+type Surprise struct {
+	Expected     Prediction `json:"expected"`
+	ActualDelta  float64    `json:"actual_delta"`
+	ActualTopics []string   `json:"actual_topics"`
+	MoodError    float64    `json:"mood_error"`     // actual - expected delta
+	TopicRecall  float64    `json:"topic_recall"`   // share of actual words that were predicted
+	Score        float64    `json:"surprise_score"` // 0 = fully expected, 1 = fully surprising
+}
+
+// Model is an online linear predictor over bag-of-words features plus the
+// current mood score. It learns with plain SGD as each turn is observed,
+// predicting how the mood score will move and which words come next.
+// Observe may be called from several goroutines; Save writes
+// the learned weights, which the caller decides when to do.
+type Model struct {
+	mu   sync.Mutex
+	path string
+	lr   float64
+
+	state modelState
+
+	// pending is the prediction made on the previous turn, still waiting
+	// for the next turn to reveal whether it was right.
+	pending *pending
+}
+
+// modelState is the persisted part of the model.
+type modelState struct {
+	Bias     float64                       `json:"bias"`
+	MoodCoef float64                       `json:"mood_coef"`
+	Delta    map[string]float64            `json:"delta"`  // token → mood delta weight
+	Topics   map[string]map[string]float64 `json:"topics"` // token → next token → weight
+	Turns    int                           `json:"turns"`
+}
+
+type pending struct {
+	tokens []string
+	score  float64
+	pred   Prediction
+}
+
+const (
+	topicRate  = 0.5  // learning rate for next-topic associations
+	topicCount = 5    // how many topics a prediction lists
+	moodScale  = 5.0  // mood score is clamped to ±5; features use score/moodScale
+	pruneBelow = 1e-3 // association weights smaller than this are dropped
+
+	// The topic associations grow with the vocabulary squared, so both
+	// the tokens tracked and the associations per token are capped.
+	maxTopicTokens = 2000 // tokens with associations
+	maxTopicsPer   = 50   // associations per token
+)
+
+// NewModel creates a model persisted at path, loading it if present.
+// lr is the SGD learning rate for the mood-delta head (e.g. 0.05).
+func NewModel(path string, lr float64) (*Model, error) {
+	if lr <= 0 {
+		lr = 0.05
+	}
+	m := &Model{
+		path: path,
+		lr:   lr,
+		state: modelState{
+			Delta:  make(map[string]float64),
+			Topics: make(map[string]map[string]float64),
+		},
+	}
+	if !storage.Exists(path) {
+		return m, nil
+	}
+	var st modelState
+	if err := storage.ReadJSON(path, &st); err != nil {
+		return m, err
+	}
+	if st.Delta == nil {
+		st.Delta = make(map[string]float64)
+	}
+	if st.Topics == nil {
+		st.Topics = make(map[string]map[string]float64)
+	}
+	m.state = st
+	return m, nil
+}
+
+// Observe feeds the current turn to the model. If a prediction was made on
+// the previous turn it is scored against this one and the model learns
+// from the error; the returned Surprise is nil on the first turn.
+// A new prediction is then made for the next turn.
+func (m *Model) Observe(text string, score float64) *Surprise {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tokens := uniqueTokens(text)
+
+	var s *Surprise
+	if p := m.pending; p != nil {
+		s = m.learn(p, tokens, score)
+	}
+
+	m.pending = &pending{
+		tokens: tokens,
+		score:  score,
+		pred:   m.predict(tokens, score),
+	}
+	m.state.Turns++
+	return s
+}
+
+// Save persists the learned weights.
+func (m *Model) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return storage.AtomicWriteJSON(m.path, &m.state)
+}
+
+func (m *Model) predict(tokens []string, score float64) Prediction {
+	return Prediction{
+		MoodDelta: m.predictDelta(tokens, score),
+		Topics:    m.predictTopics(tokens),
+	}
+}
+
+func (m *Model) predictDelta(tokens []string, score float64) float64 {
+	y := m.state.Bias + m.state.MoodCoef*score/moodScale
+	x := featureValue(tokens)
+	for _, t := range tokens {
+		y += m.state.Delta[t] * x
+	}
+	return y
+}
+
+// topicScores sums the association weights from each input token.
+func (m *Model) topicScores(tokens []string) map[string]float64 {
+	x := featureValue(tokens)
+	scores := make(map[string]float64)
+	for _, a := range tokens {
+		for b, w := range m.state.Topics[a] {
+			scores[b] += w * x
+		}
+	}
+	return scores
+}
+
+func (m *Model) predictTopics(tokens []string) []string {
+	scores := m.topicScores(tokens)
+	out := make([]string, 0, len(scores))
+	for w, s := range scores {
+		if s > 0 {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if scores[out[i]] != scores[out[j]] {
+			return scores[out[i]] > scores[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	if len(out) > topicCount {
+		out = out[:topicCount]
+	}
+	return out
+}
+
+// learn scores the pending prediction against the observed turn and takes
+// one SGD step on both heads.
+func (m *Model) learn(p *pending, next []string, score float64) *Surprise {
+	actualDelta := score - p.score
+	moodErr := actualDelta - p.pred.MoodDelta
+
+	// Mood-delta head: squared error.
+	x := featureValue(p.tokens)
+	m.state.Bias += m.lr * moodErr
+	m.state.MoodCoef += m.lr * moodErr * p.score / moodScale
+	for _, t := range p.tokens {
+		m.state.Delta[t] += m.lr * moodErr * x
+	}
+
+	// Topic head: squared error over the words that were either predicted
+	// or actually appeared, with targets 1 (appeared) and 0 (did not).
+	actual := make(map[string]bool, len(next))
+	for _, t := range next {
+		actual[t] = true
+	}
+	candidates := make(map[string]bool, len(next)+len(p.pred.Topics))
+	for _, t := range next {
+		candidates[t] = true
+	}
+	hits := 0
+	for _, t := range p.pred.Topics {
+		candidates[t] = true
+		if actual[t] {
+			hits++
+		}
+	}
+	scores := m.topicScores(p.tokens)
+	for b := range candidates {
+		target := 0.0
+		if actual[b] {
+			target = 1
+		}
+		err := target - scores[b]
+		for _, a := range p.tokens {
+			row := m.state.Topics[a]
+			if row == nil {
+				row = make(map[string]float64)
+				m.state.Topics[a] = row
+			}
+			row[b] += topicRate * err * x
+			if math.Abs(row[b]) < pruneBelow {
+				delete(row, b)
+			}
+		}
+	}
+
+	for _, a := range p.tokens {
+		trimRow(m.state.Topics[a], maxTopicsPer)
+	}
+	if len(m.state.Topics) > maxTopicTokens {
+		m.trimTopics()
+	}
+
+	recall := 0.0
+	if len(next) > 0 {
+		recall = float64(hits) / float64(len(next))
+	}
+	return &Surprise{
+		Expected:     p.pred,
+		ActualDelta:  actualDelta,
+		ActualTopics: next,
+		MoodError:    moodErr,
+		TopicRecall:  recall,
+		Score:        0.5*math.Min(1, math.Abs(moodErr)) + 0.5*(1-recall),
+	}
+}
+
+// trimRow drops the weakest associations in row until at most n remain.
+func trimRow(row map[string]float64, n int) {
+	if len(row) <= n {
+		return
+	}
+	for _, b := range weakest(row, len(row)-n) {
+		delete(row, b)
+	}
+}
+
+// trimTopics forgets the associations of the tokens with the least total
+// weight, leaving room below maxTopicTokens so this isn't redone every
+// turn.
+func (m *Model) trimTopics() {
+	mass := make(map[string]float64, len(m.state.Topics))
+	for a, row := range m.state.Topics {
+		for _, w := range row {
+			mass[a] += math.Abs(w)
+		}
+	}
+	for _, a := range weakest(mass, len(mass)-maxTopicTokens*9/10) {
+		delete(m.state.Topics, a)
+	}
+}
+
+// weakest returns the n keys of weights with the smallest magnitude,
+// breaking ties by key so the result is deterministic.
+func weakest(weights map[string]float64, n int) []string {
+	keys := make([]string, 0, len(weights))
+	for k := range weights {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		wi, wj := math.Abs(weights[keys[i]]), math.Abs(weights[keys[j]])
+		if wi != wj {
+			return wi < wj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys[:n]
+}
+
+// featureValue normalizes bag-of-words features so long inputs don't
+// dominate a single update.
+func featureValue(tokens []string) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	return 1 / float64(len(tokens))
+}
+
+func uniqueTokens(text string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range storage.Tokenize(text) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
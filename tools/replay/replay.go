@@ -1,5 +1,202 @@
+// Command replay re-drives a fresh NEON agent from recorded event logs and
+// diffs the behaviour it produces against what was originally recorded.
+//
+// INPUT events are read from data/events/events-*.jsonl in timestamp order
+// and fed through a brand-new agent whose state lives in a temporary root,
+// so the real beliefs and policy are never touched. The OUTPUT, PROPOSE,
+// EDIT and turn-cadence REFLECTION events it emits are then compared with
+// the recorded ones.
 package main
 
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"neon/internal/agent"
+	"neon/internal/config"
+	"neon/internal/perception"
+	"neon/internal/telemetry"
+	"neon/pkg/structs"
+)
+
+// compared lists the event types whose sequence is diffed.
+var compared = map[string]bool{
+	"OUTPUT":     true,
+	"PROPOSE":    true,
+	"EDIT":       true,
+	"REFLECTION": true,
+}
+
 func main() {
-	println("Replay harness placeholder")
+	dir := flag.String("events", telemetry.EventsDir("."), "directory holding events-*.jsonl")
+	verbose := flag.Bool("v", false, "print matching lines as well as differences")
+	seed := flag.Int64("seed", 0, "seed the replayed agent; use the recording's -seed to replay a deterministic run exactly")
+	flag.Parse()
+
+	recorded, err := telemetry.ReadEvents(*dir, 0)
+	if err != nil {
+		log.Fatalf("load events: %v", err)
+	}
+
+	var inputs []string
+	for _, ev := range recorded {
+		if ev.Type != "INPUT" {
+			continue
+		}
+		if text, ok := ev.Payload["text"].(string); ok {
+			inputs = append(inputs, text)
+		}
+	}
+	if len(inputs) == 0 {
+		log.Fatalf("no INPUT events found in %s", *dir)
+	}
+
+	replayed, dropped, err := replay(inputs, *seed)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	if dropped > 0 {
+		log.Fatalf("replay: logger dropped %d events; the comparison would be incomplete", dropped)
+	}
+
+	want := describeAll(recorded)
+	got := describeAll(replayed)
+	same, removed, added := diff(os.Stdout, want, got, *verbose)
+
+	fmt.Printf("inputs=%d recorded=%d replayed=%d matched=%d removed=%d added=%d\n",
+		len(inputs), len(want), len(got), same, removed, added)
+	if removed > 0 || added > 0 {
+		os.Exit(1)
+	}
+}
+
+// replay feeds inputs through a fresh agent rooted in a temporary directory
+// and returns the events it logged plus the number the logger dropped. A
+// non-zero seed makes the agent deterministic.
+func replay(inputs []string, seed int64) ([]structs.Event, int64, error) {
+	root, err := os.MkdirTemp("", "neon-replay-")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(root)
+
+	cfg := config.Default()
+	cfg.Agent.Seed = seed
+	if cfg.Deterministic() {
+		cfg.StopTimers()
+	}
+	logger := telemetry.NewLogger(root, telemetry.Options{
+		Buffer:         cfg.Telemetry.Buffer,
+		HealthInterval: time.Duration(cfg.Telemetry.HealthInterval),
+	}, cfg.Clock())
+	ag := agent.NewAgentWith(logger, root, cfg)
+	ag.SetOutput(io.Discard)
+	ag.SetPerceiver(flushing{perception.NewScripted(inputs...), logger})
+	if err := ag.Run(context.Background()); err != nil {
+		logger.Close()
+		return nil, 0, err
+	}
+	dropped, _ := logger.Health()["errors"].(int64)
+	logger.Close()
+
+	events, err := telemetry.ReadEvents(telemetry.EventsDir(root), 0)
+	return events, dropped, err
+}
+
+// flushing hands out percepts only once the logger has written every
+// event of the previous turn, so a fast replay can't overrun the logger's
+// buffer and lose events.
+type flushing struct {
+	perception.Perceiver
+	logger *telemetry.Logger
+}
+
+func (f flushing) Next(ctx context.Context) (perception.Percept, error) {
+	f.logger.Flush()
+	return f.Perceiver.Next(ctx)
+}
+
+// describeAll renders the compared events as one comparable line each.
+// Only reflections taken on the turn cadence are compared: those on the
+// interval or by command depend on when they happened, not on the input.
+func describeAll(events []structs.Event) []string {
+	out := make([]string, 0, len(events))
+	for _, ev := range events {
+		if !compared[ev.Type] || (ev.Type == "REFLECTION" && ev.Payload["reason"] != "turns") {
+			continue
+		}
+		out = append(out, describe(ev))
+	}
+	return out
+}
+
+func describe(ev structs.Event) string {
+	switch ev.Type {
+	case "PROPOSE":
+		return fmt.Sprintf("PROPOSE word=%v", ev.Payload["word"])
+	case "EDIT":
+		return fmt.Sprintf("EDIT word=%v text=%q", ev.Payload["word"], ev.Payload["text"])
+	case "REFLECTION":
+		// The summary's first line gives the window's times, which differ
+		// between runs unless both were seeded; the rest is compared.
+		summary, _ := ev.Payload["summary"].(string)
+		if _, rest, ok := strings.Cut(summary, "\n"); ok {
+			summary = rest
+		}
+		return fmt.Sprintf("REFLECTION turns=%v new_vocabulary=%v rules_created=%v rules_edited=%v rules_retired=%v summary=%q",
+			ev.Payload["turns"], ev.Payload["new_vocabulary"], ev.Payload["rules_created"],
+			ev.Payload["rules_edited"], ev.Payload["rules_retired"], summary)
+	default:
+		return fmt.Sprintf("%s %q", ev.Type, ev.Payload["text"])
+	}
+}
+
+// diff prints a line diff of want (recorded) against got (replayed) using
+// a longest-common-subsequence alignment. Lines only in want are prefixed
+// with "-", lines only in got with "+". It returns the counts of each kind.
+func diff(w io.Writer, want, got []string, verbose bool) (same, removed, added int) {
+	n, m := len(want), len(got)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && want[i] == got[j]:
+			if verbose {
+				fmt.Fprintln(w, " ", want[i])
+			}
+			same++
+			i++
+			j++
+		case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintln(w, "-", want[i])
+			removed++
+			i++
+		default:
+			fmt.Fprintln(w, "+", got[j])
+			added++
+			j++
+		}
+	}
+	return same, removed, added
 }
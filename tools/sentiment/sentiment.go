@@ -0,0 +1,120 @@
+// Command sentiment measures the persona lexicon against a labelled corpus.
+//
+// Each corpus line is LABEL<TAB>TEXT, with LABEL one of positive, negative
+// or neutral; blank lines and lines starting with # are skipped. A sentence
+// is classified by the sign of its score. Misclassified sentences are
+// printed, followed by per-label and overall accuracy.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"neon/internal/persona"
+)
+
+// labels are the classes in the order they are reported.
+var labels = []string{"positive", "negative", "neutral"}
+
+type example struct {
+	line  int
+	label string
+	text  string
+}
+
+func main() {
+	corpus := flag.String("corpus", "tools/sentiment/corpus.tsv", "labelled corpus to score")
+	lexPath := flag.String("lexicon", "", "lexicon JSON file (default: the built-in lexicon)")
+	verbose := flag.Bool("v", false, "print every sentence with its score, not just the misses")
+	minAcc := flag.Float64("min", 0, "exit with status 1 if overall accuracy is below this (0-1)")
+	flag.Parse()
+
+	lex := persona.DefaultLexicon()
+	if *lexPath != "" {
+		var err error
+		if lex, err = persona.LoadLexicon(*lexPath); err != nil {
+			log.Fatalf("load lexicon: %v", err)
+		}
+	}
+	examples, err := load(*corpus)
+	if err != nil {
+		log.Fatalf("load corpus: %v", err)
+	}
+	if len(examples) == 0 {
+		log.Fatalf("no examples in %s", *corpus)
+	}
+
+	total := map[string]int{}
+	right := map[string]int{}
+	correct := 0
+	for _, ex := range examples {
+		score := lex.Score(ex.text)
+		got := classify(score)
+		total[ex.label]++
+		mark := " "
+		if got == ex.label {
+			right[ex.label]++
+			correct++
+		} else {
+			mark = "✗"
+		}
+		if *verbose || got != ex.label {
+			fmt.Printf("%s %5.2f %-8s (want %-8s) line %d: %s\n", mark, score, got, ex.label, ex.line, ex.text)
+		}
+	}
+
+	for _, l := range labels {
+		if total[l] > 0 {
+			fmt.Printf("%-8s %3d/%-3d %5.1f%%\n", l, right[l], total[l], 100*float64(right[l])/float64(total[l]))
+		}
+	}
+	acc := float64(correct) / float64(len(examples))
+	fmt.Printf("accuracy %d/%d %.1f%%\n", correct, len(examples), 100*acc)
+	if acc < *minAcc {
+		os.Exit(1)
+	}
+}
+
+// classify maps a score to a label by its sign.
+func classify(score float64) string {
+	switch {
+	case score > 0:
+		return "positive"
+	case score < 0:
+		return "negative"
+	default:
+		return "neutral"
+	}
+}
+
+// load reads a corpus file.
+func load(path string) ([]example, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []example
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		label, text, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: want LABEL<TAB>TEXT", path, n)
+		}
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label != "positive" && label != "negative" && label != "neutral" {
+			return nil, fmt.Errorf("%s:%d: unknown label %q", path, n, label)
+		}
+		out = append(out, example{line: n, label: label, text: strings.TrimSpace(text)})
+	}
+	return out, sc.Err()
+}